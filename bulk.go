@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/tidwall/gjson"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ForEachScryfallBulkCard
+// Streams the card objects of a scryfall bulk data file (https://scryfall.com/docs/api/bulk-data) without loading the whole file
+// Params: the path of the bulk file and a function that is called for every card object, returning an error stops the iteration
+// Returns: an error if the file could not be read or is no bulk file, or the error returned by the function
+func ForEachScryfallBulkCard(path string, handle func(card gjson.Result) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return errors.New(path + " is not a scryfall bulk file, it must contain a json array of cards")
+	}
+	for decoder.More() {
+		var card json.RawMessage
+		if err := decoder.Decode(&card); err != nil {
+			return err
+		}
+		if err := handle(gjson.ParseBytes(card)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsCommanderCard
+// Checks if a scryfall card object can be drawn by the commander query, i.e. it is a legendary creature or planeswalker
+// (or says it can be your commander) that is legal in commander and was printed on paper
+// Params: the card object
+// Returns: true if the card is a commander
+func IsCommanderCard(card gjson.Result) bool {
+	if card.Get("legalities.commander").String() != "legal" {
+		return false
+	}
+	if !slices.ContainsFunc(card.Get("games").Array(), func(game gjson.Result) bool { return game.String() == "paper" }) {
+		return false
+	}
+	typeLine := card.Get("type_line").String()
+	oracleText := card.Get("oracle_text").String()
+	if card.Get("card_faces").Exists() { // only the front face decides if a card can be a commander
+		typeLine = card.Get("card_faces.0.type_line").String()
+		oracleText = card.Get("card_faces.0.oracle_text").String()
+	}
+	if strings.Contains(oracleText, "can be your commander") {
+		return true
+	}
+	return strings.Contains(typeLine, "Legendary") && (strings.Contains(typeLine, "Creature") || strings.Contains(typeLine, "Planeswalker"))
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"github.com/tidwall/gjson"
+	"image"
+	_ "image/jpeg" // scryfall delivers most images as jpg
+	"image/png"
+	"strconv"
+)
+
+// CardFace is one side of a card the user can flip to: a face with its own image, the image of the card turned around
+// (flip and split cards) or the card two cards meld into
+type CardFace struct {
+	Name     string
+	Card     gjson.Result // the face object, the card object itself for cards with a single face
+	Rotation int          // degrees the image is turned clockwise so the face can be read
+	images   gjson.Result // the image_uris of the face, empty for melded cards
+	meldUri  string       // the api uri of the melded card
+}
+
+// CardFaces
+// Lists the faces of a scryfall card object the image can flip through, the front face first.
+// Transform and modal double faced cards have an image for each face, flip cards are turned upside down,
+// split cards sideways (aftermath halves the other way) and meld cards show the card they meld into on their back.
+// Adventures are printed on the front face, so they have a single face.
+// Params: the scryfall card object as json
+// Returns: the faces of the card, a single face if the card can not be flipped
+func CardFaces(card string) []CardFace {
+	parsed := gjson.Parse(card)
+	front := CardFace{Name: parsed.Get("name").String(), Card: parsed, images: parsed.Get("image_uris")}
+	faces := parsed.Get("card_faces").Array()
+	switch parsed.Get("layout").String() {
+	case "transform", "modal_dfc", "double_faced_token", "reversible_card", "art_series":
+		result := make([]CardFace, 0, len(faces))
+		for _, face := range faces {
+			result = append(result, CardFace{Name: face.Get("name").String(), Card: face, images: face.Get("image_uris")})
+		}
+		if len(result) > 0 {
+			return result
+		}
+	case "flip":
+		if len(faces) == 2 {
+			front.Name, front.Card = faces[0].Get("name").String(), faces[0]
+			return []CardFace{front, {Name: faces[1].Get("name").String(), Card: faces[1], Rotation: 180, images: front.images}}
+		}
+	case "split":
+		if len(faces) == 2 {
+			rotation := 90
+			for _, keyword := range parsed.Get("keywords").Array() {
+				if keyword.String() == "Aftermath" { // only the lower half is printed sideways, counterclockwise
+					rotation = 270
+				}
+			}
+			return []CardFace{front, {Name: front.Name, Card: parsed, Rotation: rotation, images: front.images}}
+		}
+	case "meld":
+		for _, part := range parsed.Get("all_parts").Array() {
+			if part.Get("component").String() == "meld_result" && part.Get("name").String() != front.Name {
+				return []CardFace{front, {Name: part.Get("name").String(), meldUri: part.Get("uri").String()}}
+			}
+		}
+	}
+	if !front.images.Exists() && len(faces) > 0 { // an unknown layout with images for each face, show the front
+		front.images = faces[0].Get("image_uris")
+	}
+	return []CardFace{front}
+}
+
+// ImageUri
+// Returns the uri of the image of the face in the given size, melded cards are looked up on scryfall
+// Params: the size as named by scryfall, e.g. "border_crop" or "png"
+// Returns: the uri and an error if the melded card could not be retrieved
+func (f CardFace) ImageUri(size string) (string, error) {
+	images := f.images
+	if f.meldUri != "" {
+		card, err := GetScryfallCommanderData(f.meldUri)
+		if err != nil {
+			return "", err
+		}
+		images = gjson.Get(card, "image_uris")
+	}
+	if uri := images.Get(size).String(); uri != "" {
+		return uri, nil
+	}
+	return "", errors.New("scryfall has no image of " + f.Name)
+}
+
+// GetCardFaceImage
+// Loads the image of a card face, turned so it can be read
+// Params: the CardFace
+// Returns: the image, nil if it could not be loaded
+func GetCardFaceImage(face CardFace) fyne.Resource {
+	uri, err := face.ImageUri("border_crop")
+	if err != nil {
+		fmt.Println("ERROR: " + err.Error())
+		return nil
+	}
+	resource := GetImageResource(uri)
+	if resource == resourcePlaceholderPng {
+		return nil
+	}
+	if face.Rotation == 0 {
+		return resource
+	}
+	rotated, err := rotateImage(resource, face.Rotation)
+	if err != nil {
+		fmt.Println("ERROR: " + err.Error())
+		return resource
+	}
+	return rotated
+}
+
+// rotateImage
+// Turns an image clockwise by a multiple of 90 degrees
+// Params: the image and the degrees
+// Returns: the turned image as png and an error if the image could not be decoded
+func rotateImage(resource fyne.Resource, degrees int) (fyne.Resource, error) {
+	source, _, err := image.Decode(bytes.NewReader(resource.Content()))
+	if err != nil {
+		return nil, err
+	}
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	var rotated *image.NRGBA
+	if degrees%180 == 0 {
+		rotated = image.NewNRGBA(image.Rect(0, 0, width, height))
+	} else {
+		rotated = image.NewNRGBA(image.Rect(0, 0, height, width))
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pixel := source.At(bounds.Min.X+x, bounds.Min.Y+y)
+			switch degrees % 360 {
+			case 90:
+				rotated.Set(height-1-y, x, pixel)
+			case 180:
+				rotated.Set(width-1-x, height-1-y, pixel)
+			case 270:
+				rotated.Set(y, width-1-x, pixel)
+			default:
+				rotated.Set(x, y, pixel)
+			}
+		}
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, rotated); err != nil {
+		return nil, err
+	}
+	return fyne.NewStaticResource(resource.Name()+"-"+strconv.Itoa(degrees)+".png", encoded.Bytes()), nil
+}
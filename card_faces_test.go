@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCardFaces(t *testing.T) {
+	tests := []struct {
+		name      string
+		card      string
+		faces     []string
+		rotations []int
+		images    []string // the border_crop image of each face, "" for melded cards
+	}{
+		{
+			name:      "single faced",
+			card:      `{"name":"Sol Ring","layout":"normal","image_uris":{"border_crop":"sol"}}`,
+			faces:     []string{"Sol Ring"},
+			rotations: []int{0},
+			images:    []string{"sol"},
+		},
+		{
+			name: "transform",
+			card: `{"name":"Delver of Secrets // Insectile Aberration","layout":"transform","card_faces":[
+				{"name":"Delver of Secrets","image_uris":{"border_crop":"delver"}},
+				{"name":"Insectile Aberration","image_uris":{"border_crop":"aberration"}}]}`,
+			faces:     []string{"Delver of Secrets", "Insectile Aberration"},
+			rotations: []int{0, 0},
+			images:    []string{"delver", "aberration"},
+		},
+		{
+			name: "flip",
+			card: `{"name":"Bushi Tenderfoot // Kenzo the Hardhearted","layout":"flip","image_uris":{"border_crop":"bushi"},"card_faces":[
+				{"name":"Bushi Tenderfoot"},{"name":"Kenzo the Hardhearted"}]}`,
+			faces:     []string{"Bushi Tenderfoot", "Kenzo the Hardhearted"},
+			rotations: []int{0, 180},
+			images:    []string{"bushi", "bushi"},
+		},
+		{
+			name: "split",
+			card: `{"name":"Fire // Ice","layout":"split","image_uris":{"border_crop":"fire"},"card_faces":[
+				{"name":"Fire"},{"name":"Ice"}]}`,
+			faces:     []string{"Fire // Ice", "Fire // Ice"},
+			rotations: []int{0, 90},
+			images:    []string{"fire", "fire"},
+		},
+		{
+			name: "aftermath",
+			card: `{"name":"Cut // Ribbons","layout":"split","keywords":["Aftermath"],"image_uris":{"border_crop":"cut"},"card_faces":[
+				{"name":"Cut"},{"name":"Ribbons"}]}`,
+			faces:     []string{"Cut // Ribbons", "Cut // Ribbons"},
+			rotations: []int{0, 270},
+			images:    []string{"cut", "cut"},
+		},
+		{
+			name: "meld",
+			card: `{"name":"Bruna, the Fading Light","layout":"meld","image_uris":{"border_crop":"bruna"},"all_parts":[
+				{"component":"meld_part","name":"Bruna, the Fading Light","uri":"bruna-uri"},
+				{"component":"meld_part","name":"Gisela, the Broken Blade","uri":"gisela-uri"},
+				{"component":"meld_result","name":"Brisela, Voice of Nightmares","uri":"brisela-uri"}]}`,
+			faces:     []string{"Bruna, the Fading Light", "Brisela, Voice of Nightmares"},
+			rotations: []int{0, 0},
+			images:    []string{"bruna", ""},
+		},
+		{
+			name: "adventure",
+			card: `{"name":"Bonecrusher Giant // Stomp","layout":"adventure","image_uris":{"border_crop":"giant"},"card_faces":[
+				{"name":"Bonecrusher Giant"},{"name":"Stomp"}]}`,
+			faces:     []string{"Bonecrusher Giant // Stomp"},
+			rotations: []int{0},
+			images:    []string{"giant"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			faces := CardFaces(test.card)
+			if len(faces) != len(test.faces) {
+				t.Fatalf("got %d faces, want %d", len(faces), len(test.faces))
+			}
+			for i, face := range faces {
+				if face.Name != test.faces[i] {
+					t.Errorf("face %d is named %q, want %q", i, face.Name, test.faces[i])
+				}
+				if face.Rotation != test.rotations[i] {
+					t.Errorf("face %d is turned by %d degrees, want %d", i, face.Rotation, test.rotations[i])
+				}
+				if test.images[i] == "" { // the image of melded cards is looked up on scryfall
+					if face.meldUri == "" {
+						t.Errorf("face %d has no meld uri", i)
+					}
+					continue
+				}
+				if uri, err := face.ImageUri("border_crop"); err != nil || uri != test.images[i] {
+					t.Errorf("face %d has the image %q (%v), want %q", i, uri, err, test.images[i])
+				}
+			}
+		})
+	}
+}
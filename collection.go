@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	collectionFilePreferenceKey = "collectionFile"
+	missingOnlyPreferenceKey    = "priceMissingOnly"
+)
+
+// Collection contains the number of owned copies of each card
+type Collection struct {
+	Cards map[string]int // owned copies keyed by the lower case front face name
+	Key   string         // identifies the collection file and its version, changes whenever the file changes
+}
+
+// collectionNameColumns and collectionCountColumns are the headers of the name and quantity columns of
+// Deckbox ("Name", "Count"), Moxfield ("Name", "Count"), ManaBox ("Name", "Quantity") and Dragon Shield ("Card Name", "Quantity") exports
+var collectionNameColumns = []string{"name", "card name"}
+var collectionCountColumns = []string{"count", "quantity", "qty"}
+
+var collectionCache struct {
+	sync.Mutex
+	path       string
+	modified   time.Time
+	collection Collection
+}
+
+// LoadCollection
+// Reads a collection csv export, the result is cached until the file changes
+// Params: the path of the export
+// Returns: the Collection and an error if the file could not be read or has no name column
+func LoadCollection(path string) (Collection, error) {
+	if path == "" {
+		return Collection{}, errors.New("no collection file is configured")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Collection{}, err
+	}
+	collectionCache.Lock()
+	defer collectionCache.Unlock()
+	if collectionCache.path == path && collectionCache.modified.Equal(info.ModTime()) {
+		return collectionCache.collection, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Collection{}, err
+	}
+	cards, err := ParseCollectionCsv(string(content))
+	if err != nil {
+		return Collection{}, err
+	}
+	fmt.Println("Collection loaded from: " + path)
+	collection := Collection{Cards: cards, Key: collectionVersionKey(path, info)}
+	collectionCache.path, collectionCache.modified, collectionCache.collection = path, info.ModTime(), collection
+	return collection, nil
+}
+
+// CollectionFileKey returns the Key the collection in a file has without reading it, "" if the file does not exist
+func CollectionFileKey(path string) string {
+	info, err := os.Stat(path)
+	if path == "" || err != nil {
+		return ""
+	}
+	return collectionVersionKey(path, info)
+}
+
+// collectionVersionKey identifies a collection file and its version by the time it was last modified
+func collectionVersionKey(path string, info os.FileInfo) string {
+	return path + "@" + strconv.FormatInt(info.ModTime().UnixNano(), 10)
+}
+
+// ParseCollectionCsv
+// Parses a collection exported as csv by Deckbox, Moxfield, ManaBox or Dragon Shield.
+// The header is searched in the first lines, because some exports start with a "sep=," line.
+// Params: the content of the export
+// Returns: the owned copies keyed by the lower case front face name and an error if no header with a name column was found
+func ParseCollectionCsv(content string) (map[string]int, error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(content, "\ufeff")))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	nameColumn, countColumn, header := -1, -1, -1
+	for i, record := range records[:min(len(records), 5)] {
+		for column, field := range record {
+			field = strings.ToLower(strings.TrimSpace(field))
+			if nameColumn < 0 && slices.Contains(collectionNameColumns, field) {
+				nameColumn = column
+			}
+			if countColumn < 0 && slices.Contains(collectionCountColumns, field) {
+				countColumn = column
+			}
+		}
+		if nameColumn >= 0 {
+			header = i
+			break
+		}
+		countColumn = -1
+	}
+	if header < 0 {
+		return nil, errors.New("the collection has no \"Name\" or \"Card Name\" column")
+	}
+	cards := make(map[string]int)
+	for _, record := range records[header+1:] {
+		if nameColumn >= len(record) || strings.TrimSpace(record[nameColumn]) == "" {
+			continue
+		}
+		count := 1
+		if countColumn >= 0 && countColumn < len(record) {
+			if c, err := strconv.Atoi(strings.TrimSpace(record[countColumn])); err == nil {
+				count = c
+			}
+		}
+		cards[collectionKey(record[nameColumn])] += count
+	}
+	return cards, nil
+}
+
+// collectionKey returns the key a card is stored with inside a collection, the lower case name of its front face
+func collectionKey(name string) string {
+	front, _, _ := strings.Cut(name, " // ")
+	return strings.ToLower(strings.TrimSpace(front))
+}
+
+// CompareWithCollection
+// Splits a decklist into the copies that are owned and the copies that are missing from the collection
+// Params: the decklist formatted as "<amount> <Cardname>" lines and the collection
+// Returns: the owned and the missing cards as "<amount> <Cardname>" lines
+func CompareWithCollection(deckList string, collection Collection) ([]string, []string) {
+	owned := make([]string, 0)
+	missing := make([]string, 0)
+	available := make(map[string]int) // copies that are not yet used by an earlier line
+	for key, count := range collection.Cards {
+		available[key] = count
+	}
+	for _, line := range strings.Split(deckList, "\n") {
+		count, name, ok := ParseDeckListLine(line)
+		if !ok {
+			continue
+		}
+		key := collectionKey(name)
+		have := min(count, available[key])
+		available[key] -= have
+		if have > 0 {
+			owned = append(owned, strconv.Itoa(have)+" "+name)
+		}
+		if count > have {
+			missing = append(missing, strconv.Itoa(count-have)+" "+name)
+		}
+	}
+	return owned, missing
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"strings"
+)
+
+// NewCollectionPanel
+// Creates the panel that compares the current deck with the collection from the settings
+// Params: the session state
+// Returns: the panel
+func NewCollectionPanel(state *SessionState) *LazyPanel {
+	return NewLazyPanel(state, "Compare with collection", "Comparing deck...", func(source deckSource) (fyne.CanvasObject, error) {
+		collection, err := LoadCollection(source.collectionFile)
+		if err != nil {
+			return nil, err
+		}
+		deckList := source.deckList(state)
+		if deckList == "" {
+			return nil, fmt.Errorf("no decklist found for %s", source.name)
+		}
+		owned, missing := CompareWithCollection(deckList, collection)
+		ownedCount, missingCount := countDeckListCards(owned), countDeckListCards(missing)
+		view := container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("You own %d of %d cards", ownedCount, ownedCount+missingCount)),
+			widget.NewLabelWithStyle(fmt.Sprintf("Missing (%d)", missingCount), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel(strings.Join(missing, "\n")),
+			widget.NewLabelWithStyle(fmt.Sprintf("Owned (%d)", ownedCount), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel(strings.Join(owned, "\n")),
+		)
+		return container.NewVScroll(view), nil
+	})
+}
+
+// countDeckListCards returns the number of cards of "<amount> <Cardname>" lines
+func countDeckListCards(lines []string) int {
+	count := 0
+	for _, line := range lines {
+		amount, _, ok := ParseDeckListLine(line)
+		if ok {
+			count += amount
+		}
+	}
+	return count
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseCollectionCsv(t *testing.T) {
+	tests := []struct {
+		name  string
+		csv   string
+		cards map[string]int
+		fails bool
+	}{
+		{
+			name:  "deckbox",
+			csv:   "Count,Tradelist Count,Name,Edition\n2,0,Sol Ring,Commander Legends\n1,0,Sol Ring,Commander 2021\n4,0,Forest,Dominaria",
+			cards: map[string]int{"sol ring": 3, "forest": 4},
+		},
+		{
+			name:  "manabox",
+			csv:   "Name,Set code,Quantity,Foil\n\"Atraxa, Praetors' Voice\",C16,1,normal\nArcane Signet,CMR,2,foil",
+			cards: map[string]int{"atraxa, praetors' voice": 1, "arcane signet": 2},
+		},
+		{
+			name:  "dragon shield with separator line",
+			csv:   "sep=,\nFolder Name,Quantity,Card Name,Set Code\nBinder,1,Command Tower,CMR",
+			cards: map[string]int{"command tower": 1},
+		},
+		{
+			name:  "double faced cards by their front face",
+			csv:   "Name,Count\nDelver of Secrets // Insectile Aberration,2",
+			cards: map[string]int{"delver of secrets": 2},
+		},
+		{
+			name:  "byte order mark and no count column",
+			csv:   "\ufeffName\nSol Ring\nSol Ring\n",
+			cards: map[string]int{"sol ring": 2},
+		},
+		{
+			name:  "invalid counts and empty names",
+			csv:   "Name,Qty\nSol Ring,many\n,3",
+			cards: map[string]int{"sol ring": 1},
+		},
+		{name: "no name column", csv: "Count,Edition\n1,CMR", fails: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cards, err := ParseCollectionCsv(test.csv)
+			if test.fails {
+				if err == nil {
+					t.Fatalf("parsed %v, want an error", cards)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(cards, test.cards) {
+				t.Errorf("got %v, want %v", cards, test.cards)
+			}
+		})
+	}
+}
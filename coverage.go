@@ -0,0 +1,174 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CoverageDrawsPerRound is the number of commanders drawn at once when searching commanders covered by the collection
+var CoverageDrawsPerRound = 8
+
+// MaxCoverageRounds is the number of rounds drawn before giving up on finding a commander with the wanted coverage
+var MaxCoverageRounds = 4
+
+const minCoveragePreferenceKey = "minCoverage"
+
+// Coverage describes how much of a deck is covered by the collection
+type Coverage struct {
+	Owned        int       // number of cards of the deck that are owned
+	Total        int       // number of cards of the deck
+	MissingPrice DeckPrice // price of the cards that are not owned
+}
+
+// Percent returns the share of owned cards in percent
+func (c Coverage) Percent() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return 100 * float64(c.Owned) / float64(c.Total)
+}
+
+// String formats the coverage for the label below the card image
+func (c Coverage) String() string {
+	return fmt.Sprintf("%.0f%% owned (%d of %d cards), missing cards: %.2f€", c.Percent(), c.Owned, c.Total, c.MissingPrice.Total)
+}
+
+// CoverageCandidate is a commander whose deck is covered by the collection
+type CoverageCandidate struct {
+	Name     string
+	ImageUri string
+	Card     string
+	Coverage Coverage
+}
+
+// ComputeCoverage
+// Calculates how much of a decklist is covered by the collection, without pricing the missing cards
+// Params: the decklist formatted as "<amount> <Cardname>" lines and the collection
+// Returns: the Coverage and the missing cards as "<amount> <Cardname>" lines
+func ComputeCoverage(deckList string, collection Collection) (Coverage, []string) {
+	owned, missing := CompareWithCollection(deckList, collection)
+	coverage := Coverage{Owned: countDeckListCards(owned)}
+	coverage.Total = coverage.Owned + countDeckListCards(missing)
+	return coverage, missing
+}
+
+// FindCoveredCommanders
+// Draws a round of random commanders and keeps those whose deck is covered by the collection to at least the minimum coverage of the search
+// Params: the session state, the search and the collection
+// Returns: the candidates, best coverage first and cheapest missing cards first for equal coverage
+func FindCoveredCommanders(state *SessionState, search commanderSearch, collection Collection) []CoverageCandidate {
+	candidates := make([]CoverageCandidate, 0)
+	drawn := make(map[string]bool)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for range CoverageDrawsPerRound {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name, imageUri, card := GetCommanderFromScryfall(search.selected, search.query)
+			mutex.Lock()
+			duplicate := name == "" || drawn[name] || slices.Contains(search.drawn, name)
+			drawn[name] = true
+			mutex.Unlock()
+			if duplicate {
+				return
+			}
+			deckList := search.deckSource(name).deckList(state)
+			if deckList == "" {
+				return
+			}
+			coverage, missing := ComputeCoverage(deckList, collection)
+			if coverage.Percent() < search.minCoverage {
+				return
+			}
+			coverage.MissingPrice = GetDeckPricingData(missing, search.source.options)
+			coverage.MissingPrice.MissingOnly = true
+			if search.budget > 0 && coverage.MissingPrice.Total > search.budget {
+				return
+			}
+			mutex.Lock()
+			candidates = append(candidates, CoverageCandidate{Name: name, ImageUri: imageUri, Card: card, Coverage: coverage})
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+	RankCoverageCandidates(candidates)
+	return candidates
+}
+
+// RankCoverageCandidates sorts the candidates by coverage, the candidate with the cheapest missing cards first if the coverage is equal
+func RankCoverageCandidates(candidates []CoverageCandidate) {
+	slices.SortStableFunc(candidates, func(a CoverageCandidate, b CoverageCandidate) int {
+		if c := cmp.Compare(b.Coverage.Percent(), a.Coverage.Percent()); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Coverage.MissingPrice.Total, b.Coverage.MissingPrice.Total)
+	})
+}
+
+// coverageQueueKey identifies the colors, query, coverage, budget and version of the collection a coverage queue is drawn for
+func (s commanderSearch) coverageQueueKey() string {
+	sortedColors := slices.Clone(s.selected)
+	slices.Sort(sortedColors)
+	return strings.Join(sortedColors, "") + "|" + s.query + "|" + strconv.FormatFloat(s.minCoverage, 'f', -1, 64) + "|" + strconv.FormatFloat(s.budget, 'f', -1, 64) + "|" + CollectionFileKey(s.source.collectionFile)
+}
+
+// drawCoveredCommander
+// Offers the best ranked commander of the coverage queue. Once the queue is empty it is filled with new rounds of draws in the background,
+// a queue drawn for other colors, another query, coverage, budget or collection is discarded. The queue is only changed on the UI thread.
+// Params: the session state, the search and the callbacks of GetNextCommanderData
+func drawCoveredCommander(state *SessionState, search commanderSearch, progress func(string), done func(fyne.Resource, error)) {
+	key := search.coverageQueueKey()
+	queued := make([]CoverageCandidate, 0)
+	if state.coverageQueueKey == key {
+		queued = state.coverageQueue
+	}
+	state.coverageQueue, state.coverageQueueKey = nil, ""
+	go func() {
+		candidates, err := queued, error(nil)
+		if len(candidates) == 0 {
+			candidates, err = findCoverageQueue(state, search, progress)
+		}
+		var commander drawnCommander
+		if err == nil {
+			card, imageUri := candidates[0].Card, candidates[0].ImageUri
+			if pinned, pinnedUri, ok := pinnedCommanderCard(search.pins, card); ok {
+				card, imageUri = pinned, pinnedUri
+			}
+			commander = drawnCommander{name: candidates[0].Name, card: card, image: GetImageResource(imageUri), coverage: &candidates[0].Coverage}
+		}
+		RunOnUi(func() {
+			if err != nil {
+				done(nil, err)
+				return
+			}
+			state.coverageQueue, state.coverageQueueKey = candidates[1:], key
+			done(addDrawnCommander(state, commander), nil)
+		})
+	}()
+}
+
+// findCoverageQueue
+// Draws rounds of commanders until one is covered enough by the collection
+// Params: the session state, the search and a callback reporting the progress on the UI thread
+// Returns: the ranked candidates and an error if the collection could not be loaded or no commander was covered enough
+func findCoverageQueue(state *SessionState, search commanderSearch, progress func(string)) ([]CoverageCandidate, error) {
+	collection, err := LoadCollection(search.source.collectionFile)
+	if err != nil {
+		return nil, err
+	}
+	for round := range MaxCoverageRounds {
+		RunOnUi(func() {
+			progress(fmt.Sprintf("Comparing decks with the collection (round %d of %d)...", round+1, MaxCoverageRounds))
+		})
+		if candidates := FindCoveredCommanders(state, search, collection); len(candidates) > 0 {
+			return candidates, nil
+		}
+	}
+	return nil, fmt.Errorf("no commander with a deck covered to %.0f%% found in %d draws", search.minCoverage, CoverageDrawsPerRound*MaxCoverageRounds)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"github.com/tidwall/gjson"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var ExchangeRateUrl = "https://api.frankfurter.app/latest?from=USD&to=EUR"
+
+// exchangeRateClient gives up on a slow exchange rate api, the cached rate is used meanwhile
+var exchangeRateClient = &http.Client{Timeout: 10 * time.Second}
+
+// exchangeRateFetching is true while the exchange rate is fetched
+var exchangeRateFetching atomic.Bool
+
+// exchangeRateMaxAge is the time a fetched exchange rate is reused before it is retrieved again
+const exchangeRateMaxAge = 24 * time.Hour
+
+// exchangeRateRetryDelay is the time the exchange rate is not fetched again after a failed attempt
+const exchangeRateRetryDelay = time.Hour
+
+const (
+	usdToEurRatePreferenceKey       = "usdToEurRate"       // rate configured by the user, 0 if it shall be fetched
+	cachedUsdToEurRatePreferenceKey = "cachedUsdToEurRate" // last fetched rate
+	cachedUsdToEurTimePreferenceKey = "cachedUsdToEurTime" // unix time of the last fetch
+	failedUsdToEurTimePreferenceKey = "failedUsdToEurTime" // unix time of the last failed fetch
+)
+
+// GetUsdToEurRate
+// Determines the exchange rate used to convert dollar prices into euro without waiting for the network.
+// A rate configured in the settings always wins, otherwise the cached rate is returned and refreshed in the background once it expires.
+// After a failed fetch the cached rate is kept for a while, so an unreachable api is not asked again for every price.
+// Params: the preferences of the app
+// Returns: the amount of euro for one dollar, 0 if no rate is known yet
+func GetUsdToEurRate(preferences fyne.Preferences) float64 {
+	if configured := preferences.Float(usdToEurRatePreferenceKey); configured > 0 {
+		return configured
+	}
+	cached := preferences.Float(cachedUsdToEurRatePreferenceKey)
+	fetchedAt := time.Unix(int64(preferences.Int(cachedUsdToEurTimePreferenceKey)), 0)
+	failedAt := time.Unix(int64(preferences.Int(failedUsdToEurTimePreferenceKey)), 0)
+	if (cached <= 0 || time.Since(fetchedAt) >= exchangeRateMaxAge) && time.Since(failedAt) >= exchangeRateRetryDelay {
+		refreshUsdToEurRate(preferences)
+	}
+	return cached // an outdated rate is still better than none
+}
+
+// refreshUsdToEurRate fetches the exchange rate in the background and caches it, nothing is fetched while a fetch is running already
+func refreshUsdToEurRate(preferences fyne.Preferences) {
+	if !exchangeRateFetching.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer exchangeRateFetching.Store(false)
+		rate, err := fetchUsdToEurRate()
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+			preferences.SetInt(failedUsdToEurTimePreferenceKey, int(time.Now().Unix()))
+			return
+		}
+		preferences.SetFloat(cachedUsdToEurRatePreferenceKey, rate)
+		preferences.SetInt(cachedUsdToEurTimePreferenceKey, int(time.Now().Unix()))
+	}()
+}
+
+// fetchUsdToEurRate
+// Retrieves the current exchange rate from the exchange rate api
+// Returns: the amount of euro for one dollar and an error if the api could not be reached or returned no rate
+func fetchUsdToEurRate() (float64, error) {
+	response, err := exchangeRateClient.Get(ExchangeRateUrl)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return 0, errors.New("the exchange rate api responded with " + response.Status)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, err
+	}
+	rate := gjson.GetBytes(body, "rates.EUR").Float()
+	if rate <= 0 {
+		return 0, errors.New("the exchange rate api returned no rate from dollar to euro")
+	}
+	return rate, nil
+}
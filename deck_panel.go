@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DeckPanel shows the current deck grouped by card type with a search box and a preview of the hovered or clicked card.
+// The printing of the clicked card can be pinned.
+type DeckPanel struct {
+	*LazyPanel
+	preview     *canvas.Image
+	previews    sync.Map // card images keyed by their uri
+	previewed   atomic.Value
+	printings   *widget.Button
+	selected    DeckEntry         // the clicked card, its printings are shown by the printings button
+	OnPrintings func(card string) // called with the scryfall card object of the clicked card when its printings are asked for
+}
+
+// deckRow is a line of the deck view, either the header of a group or a card
+type deckRow struct {
+	header bool
+	text   string
+	entry  DeckEntry
+}
+
+// NewDeckPanel
+// Creates the deck panel, the deck is only loaded once the user asks for it
+// Params: the session state
+// Returns: the DeckPanel
+func NewDeckPanel(state *SessionState) *DeckPanel {
+	panel := &DeckPanel{preview: canvas.NewImageFromResource(nil)}
+	panel.preview.FillMode = canvas.ImageFillContain
+	panel.preview.SetMinSize(fyne.NewSize(183, 255))
+	panel.printings = widget.NewButton("Printings", OnUi(func() {
+		if panel.OnPrintings != nil && panel.selected.Card.Exists() {
+			panel.OnPrintings(panel.selected.Card.Raw)
+		}
+	}))
+	panel.printings.Disable()
+	panel.LazyPanel = NewLazyPanel(state, "Show deck", "Loading deck...", func(source deckSource) (fyne.CanvasObject, error) {
+		resolved, err := source.resolvedDeck(state)
+		if err != nil {
+			return nil, err
+		}
+		return panel.newDeckView(resolved.Deck), nil
+	})
+	return panel
+}
+
+// Reset shows the button again and removes the preview
+func (p *DeckPanel) Reset() {
+	p.preview.Resource = nil
+	p.previewed.Store("") // previews that are still loading belong to the previous deck
+	p.selected = DeckEntry{}
+	p.printings.Disable()
+	p.LazyPanel.Reset()
+}
+
+// newDeckView creates the searchable list of the deck with the card preview below it
+func (p *DeckPanel) newDeckView(deck Deck) fyne.CanvasObject {
+	rows := GroupDeckRows(deck, "")
+	list := widget.NewList(
+		func() int {
+			return len(rows)
+		},
+		func() fyne.CanvasObject {
+			return newCardRow(p.showPreview)
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			row := object.(*cardRow)
+			row.entry = rows[id].entry
+			row.TextStyle = fyne.TextStyle{Bold: rows[id].header}
+			row.SetText(rows[id].text)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		p.selected = rows[id].entry
+		if p.selected.Card.Exists() {
+			p.printings.Enable()
+		} else { // a header or a card scryfall does not know
+			p.printings.Disable()
+		}
+		p.showPreview(rows[id].entry)
+	}
+	search := widget.NewEntry()
+	search.PlaceHolder = "Search"
+	search.OnChanged = func(text string) {
+		rows = GroupDeckRows(deck, text)
+		list.UnselectAll()
+		list.Refresh()
+	}
+	return container.NewBorder(search, container.NewVBox(container.NewCenter(p.preview), container.NewCenter(p.printings)), nil, nil, list)
+}
+
+// showPreview loads the image of a card in the background and shows it below the list on the UI thread
+func (p *DeckPanel) showPreview(entry DeckEntry) {
+	uri := entry.Card.Get("image_uris.normal").String()
+	if uri == "" {
+		uri = entry.Card.Get("card_faces.0.image_uris.normal").String()
+	}
+	if uri == "" {
+		return
+	}
+	p.previewed.Store(uri)
+	go func() {
+		image, ok := p.previews.Load(uri)
+		if !ok {
+			image = GetImageResource(uri)
+			p.previews.Store(uri, image)
+		}
+		RunOnUi(func() {
+			if p.previewed.Load() != uri { // another card was hovered or the deck was reset in the meantime
+				return
+			}
+			p.preview.Resource = image.(fyne.Resource)
+			p.preview.Refresh()
+		})
+	}()
+}
+
+// GroupDeckRows
+// Groups the cards of a deck by their primary type, the commanders first, each group is preceded by a header with its number of cards
+// Params: the resolved deck and a search text the card names have to contain ("" for all cards)
+// Returns: the rows of the deck view
+func GroupDeckRows(deck Deck, search string) []deckRow {
+	search = strings.ToLower(strings.TrimSpace(search))
+	groups := map[string][]DeckEntry{"Commander": deck.Commanders}
+	for _, entry := range deck.Cards {
+		group := "Unknown"
+		if entry.Card.Exists() {
+			group = PrimaryType(entry.Card)
+		}
+		groups[group] = append(groups[group], entry)
+	}
+	rows := make([]deckRow, 0)
+	for _, group := range append(append([]string{"Commander"}, CardTypes...), "Other", "Unknown") {
+		cards := make([]deckRow, 0)
+		count := 0
+		for _, entry := range groups[group] {
+			if search != "" && !strings.Contains(strings.ToLower(exportName(entry)), search) {
+				continue
+			}
+			cards = append(cards, deckRow{text: strconv.Itoa(entry.Count) + " " + exportName(entry), entry: entry})
+			count += entry.Count
+		}
+		if len(cards) > 0 {
+			rows = append(rows, deckRow{header: true, text: group + " (" + strconv.Itoa(count) + ")"})
+			rows = append(rows, cards...)
+		}
+	}
+	return rows
+}
+
+// cardRow is a label that reports when the mouse enters it, so the hovered card can be previewed
+type cardRow struct {
+	widget.Label
+	entry   DeckEntry
+	onHover func(entry DeckEntry)
+}
+
+func newCardRow(onHover func(entry DeckEntry)) *cardRow {
+	row := &cardRow{onHover: onHover}
+	row.ExtendBaseWidget(row)
+	return row
+}
+
+func (r *cardRow) MouseIn(*desktop.MouseEvent) {
+	if r.entry.Card.Exists() {
+		r.onHover(r.entry)
+	}
+}
+
+func (r *cardRow) MouseMoved(*desktop.MouseEvent) {}
+
+func (r *cardRow) MouseOut() {}
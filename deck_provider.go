@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"github.com/tidwall/gjson"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DeckProvider is a source of decklists for commanders
+type DeckProvider interface {
+	// Name returns the name of the provider as it is shown in the settings
+	Name() string
+	// GetDeckList returns the decklist of a commander with every line formatted as "<amount> <Cardname>"
+	// Providers that do not know themes or variants ignore them
+	GetDeckList(commander string, theme string, variant DeckVariant) (string, error)
+	// CacheKey identifies the decklist GetDeckList returns for the same parameters
+	CacheKey(commander string, theme string, variant DeckVariant) string
+}
+
+const (
+	EdhrecDeckProviderName      = "EDHREC"
+	DeckExportsProviderName     = "Deck exports folder"
+	TeamDecksProviderName       = "Team deck folder"
+	deckProviderPreferenceKey   = "deckProvider"
+	deckExportsDirPreferenceKey = "deckExportsDir"
+	teamDecksDirPreferenceKey   = "teamDecksDir"
+)
+
+// DeckProviderNames contains the names of all providers in the order they are offered inside the settings
+var DeckProviderNames = []string{EdhrecDeckProviderName, DeckExportsProviderName, TeamDecksProviderName}
+
+// GetSelectedDeckProvider
+// Creates the deck provider selected in the settings
+// Params: the preferences of the app
+// Returns: the selected DeckProvider, EDHRec if nothing else is selected
+func GetSelectedDeckProvider(preferences fyne.Preferences) DeckProvider {
+	switch preferences.String(deckProviderPreferenceKey) {
+	case DeckExportsProviderName:
+		return LocalDeckProvider{Label: DeckExportsProviderName, Directory: preferences.String(deckExportsDirPreferenceKey)}
+	case TeamDecksProviderName:
+		return LocalDeckProvider{Label: TeamDecksProviderName, Directory: preferences.String(teamDecksDirPreferenceKey)}
+	default:
+		return EdhrecDeckProvider{}
+	}
+}
+
+// EdhrecDeckProvider provides the average decks of EDHRec
+type EdhrecDeckProvider struct{}
+
+func (p EdhrecDeckProvider) Name() string {
+	return EdhrecDeckProviderName
+}
+
+func (p EdhrecDeckProvider) GetDeckList(commander string, theme string, variant DeckVariant) (string, error) {
+	return GetEDHRecAvgDecklist(commander, theme, variant)
+}
+
+func (p EdhrecDeckProvider) CacheKey(commander string, theme string, variant DeckVariant) string {
+	return p.Name() + ":" + GetAverageDeckPath(commander, theme, variant)
+}
+
+// LocalDeckProvider provides decks from Archidekt or Moxfield json exports and text or MTGO decklists stored inside a folder
+type LocalDeckProvider struct {
+	Label     string
+	Directory string
+}
+
+func (p LocalDeckProvider) Name() string {
+	return p.Label
+}
+
+func (p LocalDeckProvider) CacheKey(commander string, _ string, _ DeckVariant) string {
+	return p.Label + ":" + p.Directory + ":" + commander
+}
+
+// GetDeckList
+// Searches the folder for the first deck (in alphabetical order of the file names) that is led by the commander
+// Params: the formatted name of the commander, theme and variant are ignored
+// Returns: the decklist and an error if the folder contains no deck for the commander
+func (p LocalDeckProvider) GetDeckList(commander string, _ string, _ DeckVariant) (string, error) {
+	if p.Directory == "" {
+		return "", errors.New("no folder is configured for " + p.Label)
+	}
+	files := make([]string, 0)
+	for _, pattern := range []string{"*.json", "*.txt", "*.dek"} {
+		matches, err := filepath.Glob(filepath.Join(p.Directory, pattern))
+		if err != nil {
+			return "", err
+		}
+		files = append(files, matches...)
+	}
+	slices.Sort(files)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+			continue
+		}
+		deck, err := ParseImportedDeck(string(content))
+		if err != nil {
+			continue // not a commander deck
+		}
+		if slices.ContainsFunc(deck.Commanders, func(name string) bool { return EdhrecSlug(name) == commander }) {
+			fmt.Println("Deck loaded from: " + file)
+			return deck.DeckList, nil
+		}
+	}
+	return "", errors.New("no deck found for commander " + commander + " in " + p.Directory)
+}
+
+// ParseDeckExport
+// Parses a deck exported as json by Archidekt or Moxfield
+// Params: the content of the export
+// Returns: the names of the commanders of the deck and the decklist, including the commanders, formatted as "<amount> <Cardname>" lines
+func ParseDeckExport(exportJson string) ([]string, string) {
+	commanders := make([]string, 0)
+	lines := make([]string, 0)
+	addCard := func(quantity int64, name string, isCommander bool) {
+		if name == "" {
+			return
+		}
+		lines = append(lines, strconv.FormatInt(max(quantity, 1), 10)+" "+name)
+		if isCommander {
+			commanders = append(commanders, name)
+		}
+	}
+
+	export := gjson.Parse(exportJson)
+	switch {
+	case export.Get("cards.0.card.oracleCard").Exists(): // archidekt
+		for _, card := range export.Get("cards").Array() {
+			categories := make([]string, 0)
+			for _, category := range card.Get("categories").Array() {
+				categories = append(categories, category.String())
+			}
+			if slices.Contains(categories, "Maybeboard") || slices.Contains(categories, "Sideboard") {
+				continue
+			}
+			addCard(card.Get("quantity").Int(), card.Get("card.oracleCard.name").String(), slices.Contains(categories, "Commander"))
+		}
+	case export.Get("boards").Exists(): // moxfield, current api
+		for _, board := range []string{"commanders", "mainboard"} {
+			export.Get("boards." + board + ".cards").ForEach(func(_, card gjson.Result) bool {
+				addCard(card.Get("quantity").Int(), card.Get("card.name").String(), board == "commanders")
+				return true
+			})
+		}
+	default: // moxfield, older api
+		for _, board := range []string{"commanders", "mainboard"} {
+			export.Get(board).ForEach(func(_, card gjson.Result) bool {
+				addCard(card.Get("quantity").Int(), card.Get("card.name").String(), board == "commanders")
+				return true
+			})
+		}
+	}
+	return commanders, strings.Join(lines, "\n")
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"strings"
+)
+
+// deckSource is what the deck of a history entry is loaded from together with the options it is loaded and priced with.
+// It is captured on the UI thread, so the deck can be loaded in the background while the history and the options change.
+type deckSource struct {
+	name           string        // formatted name of the commander
+	commander      string        // name of the commander card
+	theme          string        // slug of the theme, "" for all decks
+	imported       *ImportedDeck // nil if the deck comes from the deck provider
+	card           string        // scryfall card object of the commander as json, "" if the source is not a history entry
+	provider       DeckProvider
+	variant        DeckVariant
+	options        PricingOptions
+	missingOnly    bool          // only the cards missing from the collection are priced
+	collectionFile string        // the collection the missing cards are determined with
+	prefetch       *DeckPrefetch // the decklist and price loaded in the background, nil if they are not loaded for these options
+}
+
+// newDeckSource
+// Captures the deck of a commander with the deck provider, variant and pricing options currently selected
+// Params: the session state, the formatted name of the commander, the slug of the theme ("" for all decks) and the imported deck (nil for the deck provider)
+// Returns: the deckSource
+func newDeckSource(state *SessionState, name string, theme string, imported *ImportedDeck) deckSource {
+	return deckSource{
+		name:           name,
+		commander:      name,
+		theme:          theme,
+		imported:       imported,
+		provider:       GetSelectedDeckProvider(state.preferences),
+		variant:        state.deckVariant,
+		options:        GetPricingOptions(state),
+		missingOnly:    state.preferences.Bool(missingOnlyPreferenceKey),
+		collectionFile: state.preferences.String(collectionFilePreferenceKey),
+	}
+}
+
+// currentDeckSource returns what the deck of the displayed commander is loaded from and false if no commander is displayed
+func currentDeckSource(state *SessionState) (deckSource, bool) {
+	if len(state.prevCommanderImages) == 0 {
+		return deckSource{}, false
+	}
+	index := state.commanderCount - state.backSteps
+	source := newDeckSource(state, state.prevCommanderNames[index], state.prevCommanderThemes[index], state.prevCommanderDecks[index])
+	source.card = state.prevCommanderCards[index]
+	if commander := gjson.Get(source.card, "name").String(); commander != "" {
+		source.commander = commander
+	}
+	key := source.key()
+	state.mutex.Lock()
+	if prefetch := state.prevCommanderDeckPrefetches[index]; prefetch != nil && prefetch.key == key {
+		source.prefetch = prefetch
+	}
+	state.mutex.Unlock()
+	return source, true
+}
+
+// deckKey identifies the decklist of the source
+func (s deckSource) deckKey() string {
+	if s.imported != nil {
+		return "import:" + s.imported.DeckList
+	}
+	return s.provider.CacheKey(s.name, s.theme, s.variant)
+}
+
+// key identifies the deck of the source together with the options it is priced with, a prefetch with another key is outdated
+func (s deckSource) key() string {
+	return fmt.Sprintf("%s|%s|%s|%t|%s", s.deckKey(), s.options.Strategy, s.options.Provider.Name(), s.missingOnly, s.collectionFile)
+}
+
+// deckList
+// Loads the decklist of the source, retrieving it from the deck provider only if it is not cached yet. Imported decks are used as they are.
+// Params: the session state
+// Returns: the decklist, an empty string if it could not be retrieved
+func (s deckSource) deckList(state *SessionState) string {
+	if s.prefetch != nil { // loaded or loading in the background already
+		return s.prefetch.WaitDeckList()
+	}
+	if s.imported != nil {
+		return s.imported.DeckList
+	}
+	key := s.deckKey()
+	state.mutex.Lock()
+	deckList, ok := state.deckCache[key]
+	state.mutex.Unlock()
+	if ok {
+		return deckList
+	}
+	deckList, err := s.provider.GetDeckList(s.name, s.theme, s.variant)
+	if err != nil {
+		fmt.Println("ERROR: " + err.Error())
+		return ""
+	}
+	state.mutex.Lock()
+	state.deckCache[key] = deckList
+	state.mutex.Unlock()
+	return deckList
+}
+
+// price
+// Prices the deck of the source with its strategy and price provider, calculating it only if it is not cached yet
+// Params: the session state
+// Returns: the price of the deck, with a total of 0 if there is no decklist
+func (s deckSource) price(state *SessionState) DeckPrice {
+	if s.prefetch != nil {
+		return s.prefetch.WaitPrice()
+	}
+	key := s.deckKey() + "|" + string(s.options.Strategy) + "|" + s.options.Provider.Name()
+	// with the "only missing cards" setting the price answers what still has to be bought
+	var collection Collection
+	missingOnly := s.missingOnly
+	if missingOnly {
+		var err error
+		collection, err = LoadCollection(s.collectionFile)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+			missingOnly = false
+		} else {
+			key += "|missing:" + collection.Key
+		}
+	}
+	state.mutex.Lock()
+	price, ok := state.priceCache[key]
+	state.mutex.Unlock()
+	if ok {
+		return price
+	}
+	deckList := s.deckList(state)
+	if deckList == "" {
+		return DeckPrice{Strategy: s.options.Strategy, Source: s.options.Provider.Name()}
+	}
+	lines := strings.Split(deckList, "\n")
+	if missingOnly {
+		_, lines = CompareWithCollection(deckList, collection)
+	}
+	price = GetDeckPricingData(lines, s.options)
+	price.MissingOnly = missingOnly
+	state.mutex.Lock()
+	state.priceCache[key] = price
+	state.mutex.Unlock()
+	return price
+}
+
+// deck builds the deck of the source with the commander in its own section
+// Returns: the Deck and an error if there is no decklist
+func (s deckSource) deck(state *SessionState) (Deck, error) {
+	deckList := s.deckList(state)
+	if deckList == "" {
+		return Deck{}, errors.New("no decklist found for " + s.name)
+	}
+	if s.imported != nil {
+		return NewDeck(s.imported.Name, s.imported.Commanders, deckList), nil
+	}
+	return NewDeck(s.commander, []string{s.commander}, deckList), nil
+}
+
+// resolvedDeck returns the deck of the source with the scryfall card objects of its cards, resolving it only if it is not cached yet
+// Returns: the ResolvedDeck and an error if there is no deck or scryfall could not be reached
+func (s deckSource) resolvedDeck(state *SessionState) (ResolvedDeck, error) {
+	deck, err := s.deck(state)
+	if err != nil {
+		return ResolvedDeck{}, err
+	}
+	key := s.deckList(state)
+	state.mutex.Lock()
+	resolved, ok := state.resolvedDeckCache[key]
+	state.mutex.Unlock()
+	if ok {
+		return resolved, nil
+	}
+	notFound, err := ResolveDeck(&deck, s.options.Pins)
+	if err != nil {
+		return ResolvedDeck{}, err
+	}
+	resolved = ResolvedDeck{Deck: deck, NotFound: notFound}
+	state.mutex.Lock()
+	state.resolvedDeckCache[key] = resolved
+	state.mutex.Unlock()
+	return resolved, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/tidwall/gjson"
+	"strconv"
+	"strings"
+)
+
+const detailsWidth = 300 // the width the oracle text wraps at
+
+// NewDetailsView
+// Shows the rules of a card from its scryfall card object: name, mana cost, type line, oracle text and power/toughness or loyalty
+// of each face, followed by the legality in Commander and the EDHREC rank
+// Params: the scryfall card object as json
+// Returns: the view of the details
+func NewDetailsView(card string) fyne.CanvasObject {
+	parsed := gjson.Parse(card)
+	view := container.NewVBox()
+	faces := parsed.Get("card_faces").Array()
+	if len(faces) == 0 {
+		faces = []gjson.Result{parsed}
+	}
+	for i, face := range faces {
+		if i > 0 {
+			view.Add(widget.NewSeparator())
+		}
+		view.Add(container.NewHBox(
+			widget.NewLabelWithStyle(face.Get("name").String(), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			NewManaText(face.Get("mana_cost").String(), fyne.TextStyle{}, detailsWidth),
+		))
+		view.Add(widget.NewLabelWithStyle(face.Get("type_line").String(), fyne.TextAlignLeading, fyne.TextStyle{Italic: true}))
+		if oracle := face.Get("oracle_text").String(); oracle != "" {
+			view.Add(container.NewPadded(NewManaText(oracle, fyne.TextStyle{}, detailsWidth)))
+		}
+		if stats := cardStats(face); stats != "" {
+			view.Add(widget.NewLabelWithStyle(stats, fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}))
+		}
+	}
+	view.Add(widget.NewSeparator())
+	legality := strings.ReplaceAll(parsed.Get("legalities.commander").String(), "_", " ")
+	if legality == "" {
+		legality = "unknown"
+	}
+	view.Add(widget.NewLabel("Commander: " + legality))
+	if rank := parsed.Get("edhrec_rank"); rank.Exists() {
+		view.Add(widget.NewLabel("EDHREC rank: #" + strconv.FormatInt(rank.Int(), 10)))
+	}
+	return view
+}
+
+// cardStats formats the power and toughness, loyalty or defense of a card face, "" if it has none
+func cardStats(face gjson.Result) string {
+	switch {
+	case face.Get("power").Exists():
+		return face.Get("power").String() + "/" + face.Get("toughness").String()
+	case face.Get("loyalty").Exists():
+		return "Loyalty: " + face.Get("loyalty").String()
+	case face.Get("defense").Exists():
+		return "Defense: " + face.Get("defense").String()
+	}
+	return ""
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var EdhrecBaseUrl = "https://edhrec.com"
+var EdhrecJsonBaseUrl = "https://json.edhrec.com/pages"
+
+// BuildIdMaxAge is the time a discovered buildId is reused before it is discovered again
+var BuildIdMaxAge = 6 * time.Hour
+
+// BuildIdMinAge is the time a discovered buildId is trusted even if the data route responds with 404, the page itself is missing then
+var BuildIdMinAge = 5 * time.Minute
+
+// nextDataRegex matches the script block of a Next.js page that contains the buildId
+var nextDataRegex = regexp.MustCompile(`<script id="__NEXT_DATA__" type="application/json">(.*?)</script>`)
+
+// errEdhrecNotFound is returned when EDHRec responds with 404
+var errEdhrecNotFound = errors.New("EDHRec page not found")
+
+// buildIdCache holds the last discovered buildId
+var buildIdCache struct {
+	sync.Mutex
+	id        string
+	fetchedAt time.Time
+}
+
+// DeckVariant
+// Selects which of the average decks EDHRec publishes for a commander is used
+type DeckVariant string
+
+const (
+	DeckVariantAverage   DeckVariant = "Average"
+	DeckVariantBudget    DeckVariant = "Budget"
+	DeckVariantExpensive DeckVariant = "Expensive"
+)
+
+// DeckVariants contains all variants in the order they are offered inside the UI
+var DeckVariants = []DeckVariant{DeckVariantAverage, DeckVariantBudget, DeckVariantExpensive}
+
+// EdhrecTheme is a theme or tribe EDHRec publishes separate decks for, e.g. "Tokens" or "+1/+1 Counters"
+type EdhrecTheme struct {
+	Name  string
+	Slug  string
+	Count int // number of decks on EDHRec with this theme
+}
+
+// Label
+// Formats the theme for the theme selection in the UI
+// Returns: the name of the theme followed by its number of decks
+func (t EdhrecTheme) Label() string {
+	return t.Name + " (" + strconv.Itoa(t.Count) + ")"
+}
+
+// GetAverageDeckPath
+// Builds the EDHRec page path of an average deck, e.g. "average-decks/atraxa-praetors-voice/counters/budget"
+// Params: the formatted name of the commander, the slug of the theme ("" for all decks) and the variant of the deck
+// Returns: the path without leading slash and file extension
+func GetAverageDeckPath(commander string, theme string, variant DeckVariant) string {
+	path := "average-decks/" + commander
+	if theme != "" {
+		path += "/" + theme
+	}
+	switch variant {
+	case DeckVariantBudget:
+		path += "/budget"
+	case DeckVariantExpensive:
+		path += "/expensive"
+	}
+	return path
+}
+
+// GetEDHRecPageData
+// Retrieves the data EDHRec renders a page with.
+// The page is retrieved from EDHRec's json endpoints which do not depend on the buildId, a 404 there means EDHRec has no such page,
+// e.g. a theme without budget decks. Only if the endpoints can not be reached, the Next.js data route is tried.
+// A 404 of the data route usually means EDHRec deployed a new build, so a buildId that is not brand new is refreshed once.
+// Params: the path of the page and the formatted name of the commander the page belongs to
+// Returns: the "data" object of the page as a json string and an error if the page could not be retrieved from either source
+func GetEDHRecPageData(path string, commander string) (string, error) {
+	dataJson, err := getEdhrecJson(EdhrecJsonBaseUrl + "/" + path + ".json")
+	if err == nil {
+		return dataJson, nil
+	}
+	if errors.Is(err, errEdhrecNotFound) {
+		return "", fmt.Errorf("could not retrieve %s from EDHRec: %w", path, err)
+	}
+	fmt.Println("ERROR: " + err.Error() + ", falling back to the data route")
+	dataJson, nextErr := getEDHRecNextData(path, commander)
+	if errors.Is(nextErr, errEdhrecNotFound) && InvalidateBuildId(BuildIdMinAge) { // the cached buildId might belong to an outdated build
+		dataJson, nextErr = getEDHRecNextData(path, commander)
+	}
+	if nextErr != nil {
+		return "", fmt.Errorf("could not retrieve %s from EDHRec: %w", path, errors.Join(err, nextErr))
+	}
+	return dataJson, nil
+}
+
+// getEDHRecNextData
+// Retrieves the data of a page through the Next.js data route of EDHRec
+// Params: the path of the page and the formatted name of the commander the page belongs to
+// Returns: the "data" object of the page as a json string and an error if the page could not be retrieved
+func getEDHRecNextData(path string, commander string) (string, error) {
+	buildId, err := GetBuildId()
+	if err != nil {
+		return "", err
+	}
+	pageJson, err := getEdhrecJson(EdhrecBaseUrl + "/_next/data/" + buildId + "/" + path + ".json?commanderName=" + url.QueryEscape(commander))
+	if err != nil {
+		return "", err
+	}
+	data := gjson.Get(pageJson, "pageProps.data")
+	if !data.Exists() {
+		return "", errors.New("the data route of " + path + " contains no page data")
+	}
+	return data.Raw, nil
+}
+
+// getEdhrecJson
+// Sends a HTTP GET request to an EDHRec endpoint
+// Params: the endpoint as a string
+// Returns: the response body and an error wrapping errEdhrecNotFound if EDHRec does not know the endpoint
+func getEdhrecJson(endpoint string) (string, error) {
+	fmt.Println("Retrieving EDHRec page: " + endpoint)
+	response, err := http.Get(endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", errEdhrecNotFound, endpoint)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", errors.New("EDHRec responded with " + response.Status + " for " + endpoint)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// GetBuildId
+// Returns the buildId of EDHRec's current Next.js build which is needed for queries against its data routes.
+// The id is discovered from the EDHRec homepage and reused until it expires or is invalidated.
+// Param: None
+// Return: the buildId as a String and an error if it could not be discovered
+func GetBuildId() (string, error) {
+	buildIdCache.Lock()
+	defer buildIdCache.Unlock()
+	if buildIdCache.id != "" && time.Since(buildIdCache.fetchedAt) < BuildIdMaxAge {
+		return buildIdCache.id, nil
+	}
+	id, err := discoverBuildId()
+	if err != nil {
+		return "", fmt.Errorf("could not discover the EDHRec buildId: %w", err)
+	}
+	fmt.Println("ID EQUALS: " + id)
+	buildIdCache.id = id
+	buildIdCache.fetchedAt = time.Now()
+	return id, nil
+}
+
+// InvalidateBuildId
+// Forgets the cached buildId so the next request discovers it again, a buildId discovered within the minimum age is kept
+// Params: the minimum age of the buildId
+// Returns: true if the buildId was forgotten
+func InvalidateBuildId(minAge time.Duration) bool {
+	buildIdCache.Lock()
+	defer buildIdCache.Unlock()
+	if buildIdCache.id == "" || time.Since(buildIdCache.fetchedAt) < minAge {
+		return false
+	}
+	buildIdCache.id = ""
+	return true
+}
+
+// discoverBuildId
+// Scrapes the buildId from the __NEXT_DATA__ script block of the EDHRec homepage
+// Return: the buildId and an error if the homepage could not be retrieved or contains no buildId
+func discoverBuildId() (string, error) {
+	body, err := getEdhrecJson(EdhrecBaseUrl)
+	if err != nil {
+		return "", err
+	}
+	match := nextDataRegex.FindStringSubmatch(body)
+	if match == nil {
+		return "", errors.New("the homepage contains no __NEXT_DATA__ block")
+	}
+	id := gjson.Get(match[1], "buildId").String()
+	if id == "" {
+		return "", errors.New("the __NEXT_DATA__ block contains no buildId")
+	}
+	return id, nil
+}
+
+// GetEDHRecThemes
+// Retrieves the themes and tribes EDHRec lists for a commander
+// Params: the formatted name of the commander
+// Returns: the themes sorted by EDHRec's popularity and an error if the commander page could not be retrieved
+func GetEDHRecThemes(commander string) ([]EdhrecTheme, error) {
+	dataJson, err := GetEDHRecPageData("commanders/"+commander, commander)
+	if err != nil {
+		return nil, err
+	}
+	themes := make([]EdhrecTheme, 0)
+	for _, tag := range gjson.Get(dataJson, "panels.taglinks").Array() {
+		themes = append(themes, EdhrecTheme{
+			Name:  tag.Get("value").String(),
+			Slug:  tag.Get("slug").String(),
+			Count: int(tag.Get("count").Int()),
+		})
+	}
+	return themes, nil
+}
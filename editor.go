@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ShowDeckEditor
+// Opens a window to add, remove and swap cards of the current deck, its price and statistics follow every change.
+// The edited deck can be saved as a named variant of the commander.
+// Params: the app, the session state and a callback that is called once a variant was saved and applied to the commander
+func ShowDeckEditor(a fyne.App, state *SessionState, saved func()) {
+	w := a.NewWindow("Deck editor")
+	w.Resize(fyne.NewSize(800, 600))
+	source, ok := currentDeckSource(state)
+	if !ok {
+		dialog.ShowError(errors.New("no commander is displayed"), w)
+		w.Show()
+		return
+	}
+	// the deck is resolved in the background, the editor keeps the commander and options it was opened with
+	commander, options, variant := GetCurrentCommander(state), source.options, ""
+	if source.imported != nil {
+		variant = source.imported.Variant
+	}
+	w.SetContent(container.NewCenter(widget.NewLabel("Loading deck...")))
+	w.Show()
+	go func() {
+		resolved, err := source.resolvedDeck(state)
+		RunOnUi(func() {
+			if err != nil {
+				w.SetContent(container.NewCenter(widget.NewLabel("ERROR: " + err.Error())))
+				return
+			}
+			w.SetContent(newDeckEditor(a, w, state, commander, resolved, options, variant, saved))
+		})
+	}()
+}
+
+// newDeckEditor
+// Creates the content of the deck editor, every change of the deck happens on the UI thread
+// Params: the app, the editor window, the session state, the edited commander, its resolved deck, the pricing options,
+// the name of the edited variant ("" for the deck of the deck provider) and the callback of ShowDeckEditor
+// Returns: the content of the window
+func newDeckEditor(a fyne.App, w fyne.Window, state *SessionState, commander string, resolved ResolvedDeck, options PricingOptions, variant string, saved func()) fyne.CanvasObject {
+	// the resolved deck is shared with the cache, the editor works on its own copy
+	deck := Deck{Name: resolved.Deck.Name, Commanders: slices.Clone(resolved.Deck.Commanders), Cards: slices.Clone(resolved.Deck.Cards)}
+	selected := -1
+
+	// price and statistics of the edited deck
+	price := widget.NewLabel("")
+	stats := container.NewVScroll(NewStatsView(ComputeDeckStats(deck)))
+	var priceRequests atomic.Int64 // discards prices of outdated versions of the deck
+	var list *widget.List
+	refresh := func() {
+		list.Refresh()
+		stats.Content = NewStatsView(ComputeDeckStats(deck))
+		stats.Refresh()
+		request := priceRequests.Add(1)
+		price.SetText("Checking price...")
+		deckList, _, _ := ExportDeck(deck, ExportPlainText)
+		go func() {
+			p := GetDeckPricingData(strings.Split(deckList, "\n"), options)
+			RunOnUi(func() {
+				if priceRequests.Load() == request {
+					price.SetText(p.String())
+				}
+			})
+		}()
+	}
+
+	// the cards of the deck, the commanders can not be edited
+	list = widget.NewList(
+		func() int {
+			return len(deck.Cards)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, container.NewHBox(
+				widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), nil),
+				widget.NewButtonWithIcon("", theme.ContentAddIcon(), nil),
+			), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			row := object.(*fyne.Container)
+			entry := deck.Cards[id]
+			row.Objects[0].(*widget.Label).SetText(strconv.Itoa(entry.Count) + " " + exportName(entry))
+			buttons := row.Objects[1].(*fyne.Container)
+			buttons.Objects[0].(*widget.Button).OnTapped = OnUi(func() {
+				RemoveCardFromDeck(&deck, entry.Name)
+				list.UnselectAll()
+				refresh()
+			})
+			buttons.Objects[1].(*widget.Button).OnTapped = OnUi(func() {
+				AddCardToDeck(&deck, DeckEntry{Count: 1, Name: entry.Name, Card: entry.Card})
+				refresh()
+			})
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+	list.OnUnselected = func(widget.ListItemID) { // also called by UnselectAll while the UI lock is held
+		selected = -1
+	}
+
+	// card search with scryfall autocomplete
+	search := widget.NewSelectEntry(nil)
+	search.PlaceHolder = "Card name"
+	var autocompleteRequests atomic.Int64
+	search.OnChanged = func(text string) {
+		request := autocompleteRequests.Add(1)
+		go func() {
+			names, err := GetScryfallAutocomplete(text)
+			if err != nil {
+				fmt.Println("ERROR: " + err.Error())
+				return
+			}
+			RunOnUi(func() {
+				if autocompleteRequests.Load() == request {
+					search.SetOptions(names)
+				}
+			})
+		}()
+	}
+	// addCard looks up the searched card and adds it to the deck, replacing the selected card if replace is true
+	addCard := func(replace bool) {
+		name := strings.TrimSpace(search.Text)
+		if name == "" {
+			return
+		}
+		replaced := ""
+		if replace {
+			if selected < 0 || selected >= len(deck.Cards) {
+				dialog.ShowError(errors.New("select the card that is replaced"), w)
+				return
+			}
+			replaced = deck.Cards[selected].Name
+		}
+		// only the lookup happens in the background, the deck is changed on the UI thread
+		go func() {
+			cards, err := GetScryfallCollection([]string{name}, PricingOptions{Strategy: PriceStrategyPinned, Pins: options.Pins})
+			RunOnUi(func() {
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				card, ok := cards[name]
+				if !ok {
+					dialog.ShowError(errors.New("scryfall does not know the card "+name), w)
+					return
+				}
+				if replaced != "" {
+					RemoveCardFromDeck(&deck, replaced)
+					list.UnselectAll()
+				}
+				AddCardToDeck(&deck, DeckEntry{Count: 1, Name: card.Get("name").String(), Card: card})
+				search.SetText("")
+				refresh()
+			})
+		}()
+	}
+	add := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), OnUi(func() { addCard(false) }))
+	swap := widget.NewButtonWithIcon("Replace selected", theme.ViewRefreshIcon(), OnUi(func() { addCard(true) }))
+
+	// saving as a named variant
+	variantName := widget.NewEntry()
+	variantName.PlaceHolder = "Variant name"
+	variantName.SetText(variant)
+	save := widget.NewButtonWithIcon("Save variant", theme.DocumentSaveIcon(), OnUi(func() {
+		commanders := make([]string, 0, len(deck.Commanders))
+		for _, entry := range deck.Commanders {
+			commanders = append(commanders, exportName(entry))
+		}
+		deckList, _, _ := ExportDeck(deck, ExportPlainText)
+		variant := SavedVariant{Name: strings.TrimSpace(variantName.Text), Commander: commander, Commanders: commanders, DeckList: deckList}
+		if err := SaveVariant(a.Storage().RootURI().Path(), variant); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if GetCurrentCommander(state) == commander { // the main window still shows the edited commander
+			SetCurrentImportedDeck(state, &ImportedDeck{Name: deck.Name, Commanders: commanders, DeckList: deckList, Variant: variant.Name})
+			saved()
+		}
+		dialog.ShowInformation("Deck editor", "Saved the variant "+variant.Name, w)
+	}))
+
+	top := container.NewBorder(nil, nil, nil, container.NewHBox(add, swap), search)
+	bottom := container.NewBorder(nil, nil, price, save, variantName)
+	split := container.NewHSplit(list, stats)
+	split.Offset = 0.55
+	refresh()
+	return container.NewBorder(top, bottom, nil, nil, split)
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DeckEntry is a line of a decklist
+type DeckEntry struct {
+	Count int
+	Name  string
+	Card  gjson.Result // the resolved scryfall card object, does not exist until the deck is resolved
+}
+
+// Deck is a decklist with its commanders separated from the other cards
+type Deck struct {
+	Name       string
+	Commanders []DeckEntry
+	Cards      []DeckEntry
+}
+
+// ExportFormat is a file format a deck can be exported to
+type ExportFormat string
+
+const (
+	ExportPlainText  ExportFormat = "Plain text"
+	ExportMtgo       ExportFormat = "MTGO (.dek)"
+	ExportArena      ExportFormat = "MTG Arena"
+	ExportMoxfield   ExportFormat = "Moxfield"
+	ExportCsv        ExportFormat = "CSV"
+	ExportCockatrice ExportFormat = "Cockatrice (.cod)"
+	ExportForge      ExportFormat = "Forge (.dck)"
+	ExportXmage      ExportFormat = "XMage (.dck)"
+	ExportTts        ExportFormat = "Tabletop Simulator"
+)
+
+// ExportFormats contains all formats in the order they are offered inside the UI
+var ExportFormats = []ExportFormat{ExportPlainText, ExportMtgo, ExportArena, ExportMoxfield, ExportCsv, ExportCockatrice, ExportForge, ExportXmage, ExportTts}
+
+const exportDirPreferenceKey = "exportDir"
+
+// Extension
+// Returns the file extension of the format including the dot
+func (f ExportFormat) Extension() string {
+	switch f {
+	case ExportMtgo:
+		return ".dek"
+	case ExportCsv:
+		return ".csv"
+	case ExportCockatrice:
+		return ".cod"
+	case ExportForge, ExportXmage:
+		return ".dck"
+	case ExportTts:
+		return ".json"
+	default:
+		return ".txt"
+	}
+}
+
+// NeedsPrintings
+// Checks if the format contains printing information, i.e. the deck has to be resolved before it can be exported
+func (f ExportFormat) NeedsPrintings() bool {
+	return f != ExportPlainText
+}
+
+// NewDeck
+// Builds a deck from a decklist, moving the commanders out of the list into their own section
+// Params: the name of the deck, the names of the commanders and the decklist formatted as "<amount> <Cardname>" lines
+// Returns: the Deck
+func NewDeck(name string, commanders []string, deckList string) Deck {
+	deck := Deck{Name: name, Commanders: make([]DeckEntry, 0), Cards: make([]DeckEntry, 0)}
+	for _, line := range strings.Split(deckList, "\n") {
+		count, cardName, ok := ParseDeckListLine(line)
+		if !ok {
+			continue
+		}
+		if slices.ContainsFunc(commanders, func(commander string) bool { return IsSameCard(commander, cardName) }) {
+			deck.Commanders = append(deck.Commanders, DeckEntry{Count: count, Name: cardName})
+		} else {
+			deck.Cards = append(deck.Cards, DeckEntry{Count: count, Name: cardName})
+		}
+	}
+	// average decks do not always contain their commander
+	for _, commander := range commanders {
+		if !slices.ContainsFunc(deck.Commanders, func(entry DeckEntry) bool { return IsSameCard(commander, entry.Name) }) {
+			deck.Commanders = append(deck.Commanders, DeckEntry{Count: 1, Name: commander})
+		}
+	}
+	return deck
+}
+
+// IsSameCard
+// Compares two card names, ignoring case and treating the front face of a multi-faced card like the full name
+// Returns: true if both names belong to the same card
+func IsSameCard(a string, b string) bool {
+	frontA, _, _ := strings.Cut(a, " // ")
+	frontB, _, _ := strings.Cut(b, " // ")
+	return strings.EqualFold(strings.TrimSpace(frontA), strings.TrimSpace(frontB))
+}
+
+// ResolveDeck
+// Retrieves the scryfall card objects of all cards of the deck, using the pinned printing of a card if there is one
+// Params: the deck and the pinned printings keyed by card name
+// Returns: the names scryfall could not match and an error if scryfall could not be reached
+func ResolveDeck(deck *Deck, pins map[string]PinnedPrinting) ([]string, error) {
+	entries := make([]*DeckEntry, 0, len(deck.Commanders)+len(deck.Cards))
+	for i := range deck.Commanders {
+		entries = append(entries, &deck.Commanders[i])
+	}
+	for i := range deck.Cards {
+		entries = append(entries, &deck.Cards[i])
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	options := PricingOptions{Strategy: PriceStrategyPinned, Pins: pins}
+	cards := make(map[string]gjson.Result)
+	for _, chunk := range splitDeckListIntoChunks(names, (len(names)+scryfallCollectionLimit-1)/scryfallCollectionLimit) {
+		chunkCards, err := GetScryfallCollection(chunk, options)
+		if err != nil {
+			return nil, err
+		}
+		for name, card := range chunkCards {
+			cards[name] = card
+		}
+	}
+	notFound := make([]string, 0)
+	for _, entry := range entries {
+		card, ok := cards[entry.Name]
+		if ok {
+			entry.Card = card
+		} else {
+			notFound = append(notFound, entry.Name)
+		}
+	}
+	return notFound, nil
+}
+
+// ExportDeck
+// Formats a deck in the given export format
+// Params: the deck, it has to be resolved if the format needs printings, and the format
+// Returns: the exported deck, the names of the cards the format had to leave out and an error if the format is unknown
+func ExportDeck(deck Deck, format ExportFormat) (string, []string, error) {
+	content, err := "", error(nil)
+	switch format {
+	case ExportPlainText:
+		content = exportPlainText(deck)
+	case ExportMtgo:
+		content, err = exportMtgo(deck)
+	case ExportArena:
+		content = exportSections(deck, "Commander", "Deck", arenaLine)
+	case ExportMoxfield:
+		content = exportSections(deck, "// Commander", "// Mainboard", moxfieldLine)
+	case ExportCsv:
+		content, err = exportCsv(deck)
+	case ExportCockatrice:
+		content, err = exportCockatrice(deck)
+	case ExportForge:
+		content = exportForge(deck)
+	case ExportXmage:
+		content = exportXmage(deck)
+	case ExportTts: // a card without an image can not be shown on the table
+		return exportTts(deck)
+	default:
+		err = errors.New("unknown export format: " + string(format))
+	}
+	return content, nil, err
+}
+
+// WriteDeckExport
+// Writes an exported deck into the export folder, the file is named after the deck
+// Params: the export folder, the deck and the exported content
+// Returns: the path of the written file and an error if it could not be written
+func WriteDeckExport(directory string, deck Deck, format ExportFormat, content string) (string, error) {
+	if directory == "" {
+		return "", errors.New("no export folder is configured")
+	}
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return "", err
+	}
+	path := filepath.Join(directory, EdhrecSlug(deck.Name)+format.Extension())
+	return path, os.WriteFile(path, []byte(content), 0644)
+}
+
+// DefaultExportDirectory
+// Returns the folder exports are written to if the user did not configure one
+func DefaultExportDirectory() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "Documents", "CommandTower")
+}
+
+// exportName
+// Returns the name a card is exported with, the canonical scryfall name if the card was resolved
+func exportName(entry DeckEntry) string {
+	if name := entry.Card.Get("name").String(); name != "" {
+		return name
+	}
+	return entry.Name
+}
+
+func exportPlainText(deck Deck) string {
+	lines := make([]string, 0, len(deck.Commanders)+len(deck.Cards))
+	for _, entry := range append(append([]DeckEntry{}, deck.Commanders...), deck.Cards...) {
+		lines = append(lines, strconv.Itoa(entry.Count)+" "+entry.Name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exportSections
+// Formats a deck as a commander section followed by a deck section
+// Params: the deck, the headers of both sections and the function that formats a single line
+func exportSections(deck Deck, commanderHeader string, deckHeader string, line func(DeckEntry) string) string {
+	var builder strings.Builder
+	builder.WriteString(commanderHeader + "\n")
+	for _, entry := range deck.Commanders {
+		builder.WriteString(line(entry) + "\n")
+	}
+	builder.WriteString("\n" + deckHeader + "\n")
+	for _, entry := range deck.Cards {
+		builder.WriteString(line(entry) + "\n")
+	}
+	return builder.String()
+}
+
+// arenaLine formats a card as "1 Sol Ring (CMR) 472", arena only knows the front face of double faced cards
+func arenaLine(entry DeckEntry) string {
+	name := exportName(entry)
+	if layout := entry.Card.Get("layout").String(); layout != "split" && layout != "adventure" {
+		name, _, _ = strings.Cut(name, " // ")
+	}
+	if !entry.Card.Exists() {
+		return strconv.Itoa(entry.Count) + " " + name
+	}
+	return fmt.Sprintf("%d %s (%s) %s", entry.Count, name, strings.ToUpper(entry.Card.Get("set").String()), entry.Card.Get("collector_number").String())
+}
+
+// moxfieldLine formats a card as "1 Sol Ring (CMR) 472" with its full name
+func moxfieldLine(entry DeckEntry) string {
+	if !entry.Card.Exists() {
+		return strconv.Itoa(entry.Count) + " " + entry.Name
+	}
+	return fmt.Sprintf("%d %s (%s) %s", entry.Count, exportName(entry), strings.ToUpper(entry.Card.Get("set").String()), entry.Card.Get("collector_number").String())
+}
+
+// mtgoDeck is the xml structure of a MTGO .dek file
+type mtgoDeck struct {
+	XMLName              xml.Name   `xml:"Deck"`
+	Xsd                  string     `xml:"xmlns:xsd,attr"`
+	Xsi                  string     `xml:"xmlns:xsi,attr"`
+	NetDeckID            int        `xml:"NetDeckID"`
+	PreconstructedDeckID int        `xml:"PreconstructedDeckID"`
+	Cards                []mtgoCard `xml:"Cards"`
+}
+
+type mtgoCard struct {
+	CatID      string `xml:"CatID,attr"`
+	Quantity   int    `xml:"Quantity,attr"`
+	Sideboard  bool   `xml:"Sideboard,attr"`
+	Name       string `xml:"Name,attr"`
+	Annotation int    `xml:"Annotation,attr"`
+}
+
+// exportMtgo formats a deck as MTGO .dek xml, the commanders are placed in the sideboard like MTGO does
+func exportMtgo(deck Deck) (string, error) {
+	dek := mtgoDeck{Xsd: "http://www.w3.org/2001/XMLSchema", Xsi: "http://www.w3.org/2001/XMLSchema-instance"}
+	add := func(entry DeckEntry, sideboard bool) {
+		name, _, _ := strings.Cut(exportName(entry), " // ")
+		dek.Cards = append(dek.Cards, mtgoCard{
+			CatID:     entry.Card.Get("mtgo_id").String(),
+			Quantity:  entry.Count,
+			Sideboard: sideboard,
+			Name:      name,
+		})
+	}
+	for _, entry := range deck.Cards {
+		add(entry, false)
+	}
+	for _, entry := range deck.Commanders {
+		add(entry, true)
+	}
+	content, err := xml.MarshalIndent(dek, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return `<?xml version="1.0" encoding="utf-8"?>` + "\n" + string(content) + "\n", nil
+}
+
+// exportCsv formats a deck as csv with one line per card
+func exportCsv(deck Deck) (string, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	records := [][]string{{"Count", "Name", "Edition", "Collector Number", "Section"}}
+	add := func(entries []DeckEntry, section string) {
+		for _, entry := range entries {
+			records = append(records, []string{strconv.Itoa(entry.Count), exportName(entry), entry.Card.Get("set").String(), entry.Card.Get("collector_number").String(), section})
+		}
+	}
+	add(deck.Commanders, "Commander")
+	add(deck.Cards, "Mainboard")
+	if err := writer.WriteAll(records); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// clientCardName
+// Returns the name the open source clients use for a card, they only know the front face of multi-faced cards except for split cards
+func clientCardName(entry DeckEntry) string {
+	name := exportName(entry)
+	if entry.Card.Get("layout").String() != "split" {
+		name, _, _ = strings.Cut(name, " // ")
+	}
+	return name
+}
+
+// cockatriceDeck is the xml structure of a Cockatrice .cod file
+type cockatriceDeck struct {
+	XMLName  xml.Name         `xml:"cockatrice_deck"`
+	Version  int              `xml:"version,attr"`
+	DeckName string           `xml:"deckname"`
+	Comments string           `xml:"comments"`
+	Zones    []cockatriceZone `xml:"zone"`
+}
+
+type cockatriceZone struct {
+	Name  string           `xml:"name,attr"`
+	Cards []cockatriceCard `xml:"card"`
+}
+
+type cockatriceCard struct {
+	Number          int    `xml:"number,attr"`
+	Name            string `xml:"name,attr"`
+	SetShortName    string `xml:"setShortName,attr,omitempty"`
+	CollectorNumber string `xml:"collectorNumber,attr,omitempty"`
+}
+
+// exportCockatrice formats a deck as Cockatrice .cod xml, the commanders are placed in the sideboard zone
+func exportCockatrice(deck Deck) (string, error) {
+	zone := func(name string, entries []DeckEntry) cockatriceZone {
+		z := cockatriceZone{Name: name, Cards: make([]cockatriceCard, 0, len(entries))}
+		for _, entry := range entries {
+			z.Cards = append(z.Cards, cockatriceCard{
+				Number:          entry.Count,
+				Name:            clientCardName(entry),
+				SetShortName:    strings.ToUpper(entry.Card.Get("set").String()),
+				CollectorNumber: entry.Card.Get("collector_number").String(),
+			})
+		}
+		return z
+	}
+	cod := cockatriceDeck{Version: 1, DeckName: deck.Name, Zones: []cockatriceZone{zone("main", deck.Cards), zone("side", deck.Commanders)}}
+	content, err := xml.MarshalIndent(cod, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + string(content) + "\n", nil
+}
+
+// exportForge formats a deck as Forge .dck with a [Commander] section, cards are written as "1 Sol Ring|CMR"
+func exportForge(deck Deck) string {
+	var builder strings.Builder
+	builder.WriteString("[metadata]\nName=" + deck.Name + "\n")
+	section := func(header string, entries []DeckEntry) {
+		builder.WriteString(header + "\n")
+		for _, entry := range entries {
+			builder.WriteString(strconv.Itoa(entry.Count) + " " + clientCardName(entry))
+			if set := entry.Card.Get("set").String(); set != "" {
+				builder.WriteString("|" + strings.ToUpper(set))
+			}
+			builder.WriteString("\n")
+		}
+	}
+	section("[Commander]", deck.Commanders)
+	section("[Main]", deck.Cards)
+	return builder.String()
+}
+
+// exportXmage formats a deck as XMage .dck, cards are written as "1 [CMR:472] Sol Ring" and the commanders as sideboard lines
+func exportXmage(deck Deck) string {
+	var builder strings.Builder
+	builder.WriteString("NAME:" + deck.Name + "\n")
+	line := func(prefix string, entry DeckEntry) {
+		builder.WriteString(prefix + strconv.Itoa(entry.Count) + " ")
+		if entry.Card.Exists() {
+			builder.WriteString("[" + strings.ToUpper(entry.Card.Get("set").String()) + ":" + entry.Card.Get("collector_number").String() + "] ")
+		}
+		builder.WriteString(clientCardName(entry) + "\n")
+	}
+	for _, entry := range deck.Cards {
+		line("", entry)
+	}
+	for _, entry := range deck.Commanders {
+		line("SB: ", entry)
+	}
+	return builder.String()
+}
+
+// AddCardToDeck
+// Adds copies of a card to the deck, increasing the count if the deck already contains the card
+// Params: the deck and the entry of the card
+func AddCardToDeck(deck *Deck, entry DeckEntry) {
+	for i := range deck.Cards {
+		if IsSameCard(deck.Cards[i].Name, entry.Name) {
+			deck.Cards[i].Count += entry.Count
+			return
+		}
+	}
+	deck.Cards = append(deck.Cards, entry)
+}
+
+// RemoveCardFromDeck
+// Removes one copy of a card from the deck, the card is removed completely once no copy is left
+// Params: the deck and the name of the card
+func RemoveCardFromDeck(deck *Deck, name string) {
+	for i := range deck.Cards {
+		if IsSameCard(deck.Cards[i].Name, name) {
+			deck.Cards[i].Count--
+			if deck.Cards[i].Count <= 0 {
+				deck.Cards = slices.Delete(deck.Cards, i, i+1)
+			}
+			return
+		}
+	}
+}
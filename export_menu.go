@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"golang.design/x/clipboard"
+	"strings"
+)
+
+// ClipboardAvailable is false if the system clipboard could not be initialized, copying falls back to the clipboard of fyne then
+var ClipboardAvailable = false
+
+// exportDestination is where an export is written to
+type exportDestination int
+
+const (
+	exportToClipboard exportDestination = iota
+	exportToFolder
+	exportToFileDialog
+)
+
+// ShowExportMenu
+// Opens a menu below the export button offering every export format for the clipboard or as a file and the card image
+// Params: the window the menu belongs to, the session state and the button the menu is opened from
+func ShowExportMenu(w fyne.Window, state *SessionState, button fyne.CanvasObject) {
+	// export captures the displayed deck when the item is chosen, the deck is then loaded in the background
+	export := func(format ExportFormat, destination exportDestination) func() {
+		return OnUi(func() {
+			source, ok := currentDeckSource(state)
+			if !ok {
+				dialog.ShowError(errors.New("no commander is displayed"), w)
+				return
+			}
+			go exportDeck(w, state, source, format, destination)
+		})
+	}
+	items := make([]*fyne.MenuItem, 0, len(ExportFormats)+2)
+	for _, format := range ExportFormats {
+		item := fyne.NewMenuItem(string(format), nil)
+		item.ChildMenu = fyne.NewMenu("",
+			fyne.NewMenuItem("Copy to clipboard", export(format, exportToClipboard)),
+			fyne.NewMenuItem("Save to export folder", export(format, exportToFolder)),
+			fyne.NewMenuItem("Save as...", export(format, exportToFileDialog)),
+		)
+		items = append(items, item)
+	}
+	items = append(items, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("Save card image as...", OnUi(func() {
+		if len(state.prevCommanderImages) == 0 {
+			dialog.ShowError(errors.New("no card image available"), w)
+			return
+		}
+		go saveCardImage(w, GetCurrentCardFace(state))
+	})))
+	position := fyne.CurrentApp().Driver().AbsolutePositionForObject(button).Add(fyne.NewPos(0, button.Size().Height))
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), w.Canvas(), position)
+}
+
+// exportDeck
+// Exports a deck in the background and copies it to the clipboard or writes it to a file, the result is shown on the UI thread
+// Params: the window for dialogs, the session state, the captured deck, the format and where the export is written to
+func exportDeck(w fyne.Window, state *SessionState, source deckSource, format ExportFormat, destination exportDestination) {
+	showError := func(err error) {
+		RunOnUi(func() { dialog.ShowError(err, w) })
+	}
+	deck, err := source.deck(state)
+	if err != nil {
+		showError(err)
+		return
+	}
+	notFound := make([]string, 0)
+	if format.NeedsPrintings() {
+		resolved, err := source.resolvedDeck(state)
+		if err != nil {
+			showError(err)
+			return
+		}
+		deck, notFound = resolved.Deck, resolved.NotFound
+	}
+	content, leftOut, err := ExportDeck(deck, format)
+	if err != nil {
+		showError(err)
+		return
+	}
+	// showResult reports the finished export together with the cards scryfall could not resolve and the cards the format left out
+	showResult := func(message string) {
+		if len(notFound) > 0 {
+			message += ", but Scryfall did not know these cards:\n" + strings.Join(notFound, "\n")
+		}
+		if len(leftOut) > 0 {
+			message += "\n" + string(format) + " left out these cards without an image:\n" + strings.Join(leftOut, "\n")
+		}
+		if len(notFound) > 0 || len(leftOut) > 0 {
+			dialog.ShowError(errors.New(message), w)
+			return
+		}
+		dialog.ShowInformation("Export", message, w)
+	}
+	fileName := EdhrecSlug(deck.Name) + format.Extension()
+
+	switch destination {
+	case exportToClipboard:
+		RunOnUi(func() {
+			if CopyToClipboard(w, content, fileName) {
+				showResult(deck.Name + " was copied as " + string(format))
+			}
+		})
+	case exportToFolder:
+		directory := state.preferences.StringWithFallback(exportDirPreferenceKey, DefaultExportDirectory())
+		if format == ExportTts { // saved objects are only found inside the tabletop simulator folder
+			directory = TtsSavedObjectsDirectory()
+		}
+		path, err := WriteDeckExport(directory, deck, format, content)
+		if err != nil {
+			showError(err)
+			return
+		}
+		RunOnUi(func() { showResult(deck.Name + " was saved to " + path) })
+	case exportToFileDialog:
+		RunOnUi(func() {
+			ShowSaveFileDialog(w, fileName, []byte(content), func(path string) {
+				showResult(deck.Name + " was saved to " + path)
+			})
+		})
+	}
+}
+
+// saveCardImage
+// Downloads the full resolution image of a card face and lets the user save it
+// Params: the window for dialogs and the displayed card face
+func saveCardImage(w fyne.Window, face CardFace) {
+	imageUri, err := face.ImageUri("png")
+	if err != nil {
+		RunOnUi(func() { dialog.ShowError(err, w) })
+		return
+	}
+	image, err := GetScryfallCommanderData(imageUri)
+	if err != nil {
+		RunOnUi(func() { dialog.ShowError(err, w) })
+		return
+	}
+	RunOnUi(func() { ShowSaveFileDialog(w, EdhrecSlug(face.Name)+".png", []byte(image), nil) })
+}
+
+// CopyToClipboard
+// Copies text to the system clipboard, falls back to the clipboard of fyne and then to saving the text as a file if no clipboard works
+// Params: the window for the fallbacks, the text and the file name suggested if the text has to be saved
+// Returns: true if the text was copied, false if the user is asked to save it instead
+func CopyToClipboard(w fyne.Window, text string, fileName string) bool {
+	if ClipboardAvailable {
+		clipboard.Write(clipboard.FmtText, []byte(text))
+		return true
+	}
+	if fyneClipboard := w.Clipboard(); fyneClipboard != nil {
+		fyneClipboard.SetContent(text)
+		if fyneClipboard.Content() == text {
+			return true
+		}
+	}
+	fmt.Println("ERROR: no clipboard available, saving " + fileName + " instead")
+	ShowSaveFileDialog(w, fileName, []byte(text), nil)
+	return false
+}
+
+// ShowSaveFileDialog
+// Opens a save dialog and writes the content to the chosen file
+// Params: the window the dialog belongs to, the suggested file name, the content and a callback with the written path (may be nil)
+func ShowSaveFileDialog(w fyne.Window, fileName string, content []byte, saved func(path string)) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if writer == nil { // the user cancelled the dialog
+			return
+		}
+		defer writer.Close()
+		if _, err := writer.Write(content); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if saved != nil {
+			saved(writer.URI().Path())
+		}
+	}, w)
+	save.SetFileName(fileName)
+	save.Show()
+}
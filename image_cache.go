@@ -0,0 +1,136 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CacheDirectory returns the folder of the persistent cache with the given name, "" if the os has no cache folder
+func CacheDirectory(name string) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "CommandTower", name)
+}
+
+// ImageCacheDirectory returns the folder card images are cached in, "" if the os has no cache folder
+func ImageCacheDirectory() string {
+	return CacheDirectory("images")
+}
+
+// GetImageResource
+// Transforms an image URI into a fyne Resource for usage inside the UI.
+// Images are cached on disk by the hash of their content, so they are only downloaded once.
+// Params: the image URI as a string
+// Returns: a fyne.Resource representing the Image
+func GetImageResource(imageUri string) fyne.Resource {
+	name := path.Base(strings.SplitN(imageUri, "?", 2)[0])
+	directory := ImageCacheDirectory()
+	if content, err := readCachedImage(directory, imageUri); err == nil {
+		return fyne.NewStaticResource(name, content)
+	}
+	content, err := downloadImage(imageUri)
+	if err != nil { // if image can not be loaded attempt to load a placeholder
+		fmt.Println("ERROR: " + err.Error())
+		return resourcePlaceholderPng
+	}
+	if err := writeCachedImage(directory, imageUri, content); err != nil {
+		fmt.Println("ERROR: " + err.Error())
+	}
+	return fyne.NewStaticResource(name, content)
+}
+
+// hashHex returns the hex encoded sha256 hash of data
+func hashHex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// readCachedImage
+// Reads an image from the disk cache, the index maps the hash of the uri to the hash of the content inside the objects folder
+// Params: the cache folder and the uri of the image
+// Returns: the content of the image and an error if it is not cached
+func readCachedImage(directory string, imageUri string) ([]byte, error) {
+	if directory == "" {
+		return nil, errors.New("no image cache folder")
+	}
+	object, err := os.ReadFile(filepath.Join(directory, "index", hashHex([]byte(imageUri))))
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filepath.Join(directory, "objects", strings.TrimSpace(string(object))))
+	if err != nil {
+		return nil, err
+	}
+	if hashHex(content) != strings.TrimSpace(string(object)) { // e.g. a write that was interrupted
+		return nil, errors.New("corrupt cached image: " + imageUri)
+	}
+	return content, nil
+}
+
+// writeCachedImage
+// Stores an image inside the disk cache, images with the same content are only stored once
+// Params: the cache folder, the uri of the image and its content
+// Returns: an error if the image could not be written
+func writeCachedImage(directory string, imageUri string, content []byte) error {
+	if directory == "" {
+		return nil
+	}
+	object := hashHex(content)
+	for _, folder := range []string{"objects", "index"} {
+		if err := os.MkdirAll(filepath.Join(directory, folder), os.ModePerm); err != nil {
+			return err
+		}
+	}
+	objectPath := filepath.Join(directory, "objects", object)
+	if _, err := os.Stat(objectPath); errors.Is(err, os.ErrNotExist) {
+		if err := writeFileAtomic(objectPath, content); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(filepath.Join(directory, "index", hashHex([]byte(imageUri))), []byte(object))
+}
+
+// writeFileAtomic writes a file through a temporary file, so readers never see a partially written file
+func writeFileAtomic(path string, content []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// downloadImage
+// Downloads an image
+// Params: the uri of the image
+// Returns: the content of the image and an error if it could not be downloaded
+func downloadImage(imageUri string) ([]byte, error) {
+	response, err := http.Get(imageUri)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not load image %s: %s", imageUri, response.Status)
+	}
+	return io.ReadAll(response.Body)
+}
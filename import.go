@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ImportedDeck is a decklist the user imported instead of the decklist of the deck provider
+type ImportedDeck struct {
+	Name       string
+	Commanders []string
+	DeckList   string // the decklist including the commanders formatted as "<amount> <Cardname>" lines
+	Variant    string // the name of the saved variant the deck was loaded from, "" for imported lists
+}
+
+// importLineRegex matches the card lines of plain text, MTGO, Arena and Moxfield lists, e.g. "1x Sol Ring (CMR) 472 *F*"
+var importLineRegex = regexp.MustCompile(`^(?:SB:\s*)?(\d+)x?\s+(.+?)(?:\s+\([A-Za-z0-9]+\)(?:\s+[A-Za-z0-9★-]+)?)?(?:\s+\*[A-Za-z]+\*)*$`)
+
+// ParseImportedDeck
+// Parses a decklist in plain text, MTGO (.dek or text), Arena or Moxfield format or an Archidekt or Moxfield json export
+// Params: the content of the list
+// Returns: the ImportedDeck and an error if the list contains no cards or no commander
+func ParseImportedDeck(text string) (ImportedDeck, error) {
+	text = strings.TrimSpace(strings.TrimPrefix(text, "\ufeff"))
+	var deck ImportedDeck
+	switch {
+	case strings.HasPrefix(text, "{"):
+		deck.Commanders, deck.DeckList = ParseDeckExport(text)
+	case strings.HasPrefix(text, "<"):
+		var dek mtgoDeck
+		if err := xml.Unmarshal([]byte(text), &dek); err != nil {
+			return ImportedDeck{}, err
+		}
+		lines := make([]string, 0, len(dek.Cards))
+		for _, card := range dek.Cards {
+			lines = append(lines, strconv.Itoa(max(card.Quantity, 1))+" "+card.Name)
+			if card.Sideboard { // MTGO keeps the commander in the sideboard
+				deck.Commanders = append(deck.Commanders, card.Name)
+			}
+		}
+		deck.DeckList = strings.Join(lines, "\n")
+	default:
+		deck = parseImportedText(text)
+	}
+	if deck.DeckList == "" {
+		return ImportedDeck{}, errors.New("the list contains no cards")
+	}
+	if len(deck.Commanders) == 0 {
+		return ImportedDeck{}, errors.New("the list has no commander, put it into a \"Commander\" section")
+	}
+	if deck.Name == "" {
+		deck.Name = strings.Join(deck.Commanders, " & ")
+	}
+	return deck, nil
+}
+
+// parseImportedText
+// Parses a text decklist, cards of a "Commander" section become the commanders and sideboard and maybeboard sections are skipped.
+// Lists without a commander section use the last paragraph as commanders if it holds one or two cards, like MTGO and Moxfield exports do.
+func parseImportedText(text string) ImportedDeck {
+	var deck ImportedDeck
+	lines := make([]string, 0)
+	section := ""
+	hasCommanderSection := false
+	lastParagraph := make([]string, 0)
+	paragraphEnded := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			paragraphEnded = true
+			continue
+		}
+		if name, found := strings.CutPrefix(line, "Name "); found && section == "about" { // arena deck name
+			deck.Name = name
+			continue
+		}
+		header := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(line, "//")), ":"))
+		switch header {
+		case "commander", "commanders":
+			section, hasCommanderSection = "commander", true
+			continue
+		case "deck", "main", "mainboard", "companion":
+			section = "deck"
+			continue
+		case "sideboard", "maybeboard", "considering", "tokens":
+			section = "skip"
+			continue
+		case "about":
+			section = "about"
+			continue
+		}
+		match := importLineRegex.FindStringSubmatch(line)
+		if match == nil || section == "skip" || section == "about" {
+			continue
+		}
+		if paragraphEnded {
+			lastParagraph = lastParagraph[:0]
+			paragraphEnded = false
+		}
+		lines = append(lines, match[1]+" "+match[2])
+		lastParagraph = append(lastParagraph, match[2])
+		if section == "commander" || strings.HasPrefix(line, "SB:") {
+			deck.Commanders = append(deck.Commanders, match[2])
+		}
+	}
+	if !hasCommanderSection && len(deck.Commanders) == 0 && len(lastParagraph) <= 2 && len(lastParagraph) < len(lines) {
+		deck.Commanders = append(deck.Commanders, lastParagraph...)
+	}
+	deck.DeckList = strings.Join(lines, "\n")
+	return deck
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"io"
+)
+
+// ShowImportDialog
+// Opens a dialog to paste or load a decklist, the imported deck is added to the history and replaces the deck of the provider for pricing and export
+// Params: the window the dialog belongs to, the session state and a callback with the image of the imported commander, called with the UI lock held
+func ShowImportDialog(w fyne.Window, state *SessionState, imported func(image fyne.Resource)) {
+	list := widget.NewMultiLineEntry()
+	list.PlaceHolder = "Paste a decklist (plain text, MTGO, Arena or Moxfield) or load a file"
+	list.Wrapping = fyne.TextWrapOff
+	load := widget.NewButtonWithIcon("Load file", theme.FolderOpenIcon(), func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if reader == nil { // the user cancelled the dialog
+				return
+			}
+			defer reader.Close()
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			list.SetText(string(content))
+		}, w)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".txt", ".dek", ".json"}))
+		open.Show()
+	})
+
+	content := container.NewBorder(nil, load, nil, nil, list)
+	importDialog := dialog.NewCustomConfirm("Import deck", "Import", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		deck, err := ParseImportedDeck(list.Text)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		pins := state.pinnedPrintings
+		go func() {
+			card, image, err := LoadImportedCommander(deck, pins)
+			RunOnUi(func() {
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				AddImportedDeck(state, deck, card, image)
+				imported(image)
+			})
+		}()
+	}, w)
+	importDialog.Resize(fyne.NewSize(500, 500))
+	importDialog.Show()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseImportedDeck(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		deckName   string
+		commanders []string
+		deckList   string
+		fails      bool
+	}{
+		{
+			name:       "plain text with commander section",
+			text:       "Commander\n1 Atraxa, Praetors' Voice\n\nDeck\n1 Sol Ring\n10 Forest\n\nSideboard\n1 Swords to Plowshares",
+			deckName:   "Atraxa, Praetors' Voice",
+			commanders: []string{"Atraxa, Praetors' Voice"},
+			deckList:   "1 Atraxa, Praetors' Voice\n1 Sol Ring\n10 Forest",
+		},
+		{
+			name:       "last paragraph as commander",
+			text:       "1x Sol Ring\n10x Forest\n\n1x Omnath, Locus of Creation",
+			deckName:   "Omnath, Locus of Creation",
+			commanders: []string{"Omnath, Locus of Creation"},
+			deckList:   "1 Sol Ring\n10 Forest\n1 Omnath, Locus of Creation",
+		},
+		{
+			name:       "arena with set codes and about section",
+			text:       "About\nName Partners\n\nCommander\n1 Tymna the Weaver (C16) 48\n1 Thrasios, Triton Hero (C16) 46 *F*\n\nDeck\n1 Sol Ring (CMR) 472",
+			deckName:   "Partners",
+			commanders: []string{"Tymna the Weaver", "Thrasios, Triton Hero"},
+			deckList:   "1 Tymna the Weaver\n1 Thrasios, Triton Hero\n1 Sol Ring",
+		},
+		{
+			name:       "moxfield text with comment headers",
+			text:       "// Commander\n1 Krenko, Mob Boss\n// Mainboard\n30 Mountain\n// Maybeboard\n1 Goblin King",
+			deckName:   "Krenko, Mob Boss",
+			commanders: []string{"Krenko, Mob Boss"},
+			deckList:   "1 Krenko, Mob Boss\n30 Mountain",
+		},
+		{
+			name:       "mtgo text with sideboard commander",
+			text:       "1 Sol Ring\n30 Island\nSB: 1 Talrand, Sky Summoner",
+			deckName:   "Talrand, Sky Summoner",
+			commanders: []string{"Talrand, Sky Summoner"},
+			deckList:   "1 Sol Ring\n30 Island\n1 Talrand, Sky Summoner",
+		},
+		{
+			name: "mtgo dek",
+			text: `<?xml version="1.0" encoding="utf-8"?>
+<Deck xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <Cards CatID="1" Quantity="1" Sideboard="false" Name="Sol Ring" />
+  <Cards CatID="2" Quantity="30" Sideboard="false" Name="Island" />
+  <Cards CatID="3" Quantity="1" Sideboard="true" Name="Talrand, Sky Summoner" />
+</Deck>`,
+			deckName:   "Talrand, Sky Summoner",
+			commanders: []string{"Talrand, Sky Summoner"},
+			deckList:   "1 Sol Ring\n30 Island\n1 Talrand, Sky Summoner",
+		},
+		{
+			name:       "archidekt json",
+			text:       `{"cards":[{"quantity":1,"categories":["Commander"],"card":{"oracleCard":{"name":"Edgar Markov"}}},{"quantity":1,"categories":["Ramp"],"card":{"oracleCard":{"name":"Sol Ring"}}},{"quantity":1,"categories":["Maybeboard"],"card":{"oracleCard":{"name":"Bloodline Keeper"}}}]}`,
+			deckName:   "Edgar Markov",
+			commanders: []string{"Edgar Markov"},
+			deckList:   "1 Edgar Markov\n1 Sol Ring",
+		},
+		{
+			name:       "moxfield json",
+			text:       `{"boards":{"commanders":{"cards":{"a":{"quantity":1,"card":{"name":"Edgar Markov"}}}},"mainboard":{"cards":{"b":{"quantity":1,"card":{"name":"Sol Ring"}}}}}}`,
+			deckName:   "Edgar Markov",
+			commanders: []string{"Edgar Markov"},
+			deckList:   "1 Edgar Markov\n1 Sol Ring",
+		},
+		{name: "no cards", text: "Commander\nDeck", fails: true},
+		{name: "no commander", text: "1 Sol Ring\n1 Arcane Signet\n1 Command Tower", fails: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deck, err := ParseImportedDeck(test.text)
+			if test.fails {
+				if err == nil {
+					t.Fatalf("parsed %+v, want an error", deck)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deck.Name != test.deckName {
+				t.Errorf("deck is named %q, want %q", deck.Name, test.deckName)
+			}
+			if !slices.Equal(deck.Commanders, test.commanders) {
+				t.Errorf("commanders are %q, want %q", deck.Commanders, test.commanders)
+			}
+			if deck.DeckList != test.deckList {
+				t.Errorf("decklist is %q, want %q", deck.DeckList, test.deckList)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"sync/atomic"
+)
+
+// LazyPanel is a tab that shows a button until the user asks for its content, the content is then created in the background
+type LazyPanel struct {
+	container *fyne.Container
+	button    *widget.Button
+	loading   string
+	requests  atomic.Int64 // discards content of decks that are no longer displayed
+	state     *SessionState
+	create    func(source deckSource) (fyne.CanvasObject, error)
+}
+
+// NewLazyPanel
+// Creates a panel that creates its content once its button is pressed
+// Params: the session state, the label of the button, the text shown while the content is created and the function creating the content
+// from the deck displayed when the button was pressed
+// Returns: the LazyPanel
+func NewLazyPanel(state *SessionState, buttonLabel string, loadingLabel string, create func(source deckSource) (fyne.CanvasObject, error)) *LazyPanel {
+	panel := &LazyPanel{container: container.NewStack(), loading: loadingLabel, state: state, create: create}
+	panel.button = widget.NewButton(buttonLabel, OnUi(panel.Load))
+	panel.Reset()
+	return panel
+}
+
+// Reset shows the button again, e.g. because another deck is displayed
+func (p *LazyPanel) Reset() {
+	p.requests.Add(1)
+	p.container.Objects = []fyne.CanvasObject{container.NewCenter(p.button)}
+	p.container.Refresh()
+}
+
+// Load captures the displayed deck, creates the content from it in the background and shows it on the UI thread.
+// Errors are shown together with the button to try again.
+func (p *LazyPanel) Load() {
+	request := p.requests.Add(1)
+	source, ok := currentDeckSource(p.state)
+	if !ok {
+		p.show(nil, errors.New("no commander is displayed"))
+		return
+	}
+	p.container.Objects = []fyne.CanvasObject{container.NewCenter(widget.NewLabel(p.loading))}
+	p.container.Refresh()
+	go func() {
+		content, err := p.create(source)
+		RunOnUi(func() {
+			if p.requests.Load() == request {
+				p.show(content, err)
+			}
+		})
+	}()
+}
+
+// show replaces the content of the panel, an error is shown together with the button
+func (p *LazyPanel) show(content fyne.CanvasObject, err error) {
+	if err != nil {
+		fmt.Println("ERROR: " + err.Error())
+		content = container.NewCenter(container.NewVBox(widget.NewLabel(err.Error()), p.button))
+	}
+	p.container.Objects = []fyne.CanvasObject{content}
+	p.container.Refresh()
+}
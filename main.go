@@ -2,28 +2,34 @@ package main
 
 import (
 	"C"
+	"errors"
+	"flag"
+	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/tidwall/gjson"
 	"golang.design/x/clipboard"
+	"image/color"
+	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
-// GetImageResource
-// Transforms an image URI into a fyne Resource for usage inside the UI
-// Params: the image URI as a string
-// Returns: a fyne.Resource representing the Image
-func GetImageResource(imageUri string) fyne.Resource {
-	r, err := fyne.LoadResourceFromURLString(imageUri) // load image
-	if err != nil {                                    // if image can not be loaded attempt to load a placeholder
-		return resourcePlaceholderPng
-	}
-	return r
-}
+const (
+	priceStrategyPreferenceKey = "priceStrategy"
+	budgetPreferenceKey        = "budget"
+	deckVariantPreferenceKey   = "deckVariant"
+)
+
+const allThemesLabel = "All themes"
 
 func NewCheckboxWithIcon(resource *fyne.StaticResource) (*widget.Check, *fyne.Container) { // TODO: create a custom widget for this
 
@@ -33,6 +39,49 @@ func NewCheckboxWithIcon(resource *fyne.StaticResource) (*widget.Check, *fyne.Co
 	return checkBox, container.NewVBox(checkBox, img)
 }
 
+// ParseBudget
+// Parses the text of the budget entry
+// Params: the text of the entry
+// Returns: the budget in euro, 0 if no valid budget was entered
+func ParseBudget(text string) float64 {
+	budget, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "€")), 64)
+	if err != nil || budget < 0 {
+		return 0.0
+	}
+	return budget
+}
+
+// ValidateBudget
+// Validator of the budget entry, an empty entry means no budget
+func ValidateBudget(text string) error {
+	if strings.TrimSpace(text) != "" && ParseBudget(text) == 0.0 {
+		return errors.New("the budget must be a positive amount of euro")
+	}
+	return nil
+}
+
+// ParseCoverage
+// Parses the text of the coverage entry
+// Params: the text of the entry
+// Returns: the minimum coverage in percent, 0 for an empty entry, and an error if no valid coverage was entered
+func ParseCoverage(text string) (float64, error) {
+	if strings.TrimSpace(text) == "" {
+		return 0.0, nil
+	}
+	coverage, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "%")), 64)
+	if err != nil || coverage < 0 || coverage > 100 {
+		return 0.0, errors.New("the coverage must be a percentage between 0 and 100")
+	}
+	return coverage, nil
+}
+
+// ValidateCoverage
+// Validator of the coverage entry, an empty entry or 0% means that the collection is ignored
+func ValidateCoverage(text string) error {
+	_, err := ParseCoverage(text)
+	return err
+}
+
 func GetSelectedChoices(choiceColorMap map[*widget.Check]string) []string {
 	result := make([]string, 0)
 	for check, color := range choiceColorMap {
@@ -47,34 +96,65 @@ func GetSelectedChoices(choiceColorMap map[*widget.Check]string) []string {
 // Params: None
 // Returns: Nothing
 func main() {
+	// command line tools that run without the UI
+	verifySlugs := flag.String("verify-slugs", "", "path of a scryfall bulk file whose commanders are checked against the EDHRec slugs")
+	flag.Parse()
+	if *verifySlugs != "" {
+		unresolved, err := VerifySlugs(*verifySlugs, os.Stdout)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+			os.Exit(2)
+		}
+		if unresolved > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// init app
+	myApp := app.NewWithID("com.github.piwonka.commandtower")
+
 	// initialize session State
 	state := SessionState{
-		commanderCount:          -1, // -1 == we don't have any commanders; 0 == we have a commander and its index in the cache is 0; ...
-		backSteps:               0,
-		currentCardFace:         0,
-		prevCommanderNames:      make([]string, 0),
-		prevCommanderImages:     make([]fyne.Resource, 0),
-		prevCommanderDecklists:  make([]string, 0),
-		prevCommanderDeckPrices: make([]float64, 0),
+		commanderCount:      -1, // -1 == we don't have any commanders; 0 == we have a commander and its index in the cache is 0; ...
+		backSteps:           0,
+		prevCommanderNames:  make([]string, 0),
+		prevCommanderImages: make([]fyne.Resource, 0),
+		deckCache:           make(map[string]string),
+		priceCache:          make(map[string]DeckPrice),
+		themeCache:          make(map[string][]EdhrecTheme),
+		prevCommanderThemes: make([]string, 0),
+		prevCommanderCards:  make([]string, 0),
+		prevCommanderDecks:  make([]*ImportedDeck, 0),
+		resolvedDeckCache:   make(map[string]ResolvedDeck),
+		deckVariant:         DeckVariant(myApp.Preferences().StringWithFallback(deckVariantPreferenceKey, string(DeckVariantAverage))),
+		priceStrategy:       PriceStrategy(myApp.Preferences().StringWithFallback(priceStrategyPreferenceKey, string(PriceStrategyNewest))),
+		pinnedPrintings:     LoadPinnedPrintings(myApp.Preferences()),
+		preferences:         myApp.Preferences(),
 	}
+	GetUsdToEurRate(myApp.Preferences()) // starts fetching the exchange rate in the background, so it is known before the first price
 
 	// init clipboard access
 	err := clipboard.Init()
-
-	if err != nil { // end execution with error if clipboard access is blocked
-		//panic(err)
+	if err != nil { // copying falls back to the clipboard of fyne or a save dialog
+		fmt.Println("ERROR: " + err.Error())
 	}
+	ClipboardAvailable = err == nil
 
 	// Build Main View Objects
 
 	// init window
-	myApp := app.New()
 	w := myApp.NewWindow("Command Tower")
 
 	// init search field
 	searchQuery := widget.NewEntry()
 	searchQuery.PlaceHolder = "Scryfall Search Query"
 
+	// init settings
+	settings := widget.NewButtonWithIcon("", theme.SettingsIcon(), func() {
+		ShowSettingsDialog(w, myApp.Preferences())
+	})
+
 	// init checkBoxes
 	choices := container.NewHBox()
 	choiceColorMap := make(map[*widget.Check]string)
@@ -85,17 +165,55 @@ func main() {
 		choices.Add(checkContainer)
 	}
 
+	// init budget
+	budget := widget.NewEntry()
+	budget.PlaceHolder = "Budget €"
+	budget.Validator = ValidateBudget
+	if b := myApp.Preferences().Float(budgetPreferenceKey); b > 0 {
+		budget.SetText(strconv.FormatFloat(b, 'f', -1, 64))
+	}
+	budget.OnChanged = func(text string) {
+		myApp.Preferences().SetFloat(budgetPreferenceKey, ParseBudget(text))
+	}
+
+	// init coverage, only commanders whose deck is covered by the collection are offered if it is set
+	coverage := widget.NewEntry()
+	coverage.PlaceHolder = "Owned %"
+	coverage.Validator = ValidateCoverage
+	if c := myApp.Preferences().Float(minCoveragePreferenceKey); c > 0 {
+		coverage.SetText(strconv.FormatFloat(c, 'f', -1, 64))
+	}
+	coverage.OnChanged = func(text string) {
+		c, _ := ParseCoverage(text) // an invalid coverage is shown by the validator and ignores the collection
+		myApp.Preferences().SetFloat(minCoveragePreferenceKey, c)
+	}
+	// minCoverage returns the entered coverage, 0 if the collection is ignored
+	minCoverage := func() float64 {
+		c, _ := ParseCoverage(coverage.Text)
+		return c
+	}
+
 	// Image
 	img := canvas.NewImageFromResource(nil)
 	img.Resize(fyne.NewSize(480, 680))
 	img.FillMode = canvas.ImageFillOriginal
-	clickableImage := NewClickableImage(img, func() {
+	clickableImage := NewClickableImage(img, OnUi(func() {
 		res := GetOtherCardFaceForCurrentCard(&state)
 		if res != nil {
 			img.Resource = res
 			img.Refresh()
 		}
-	})
+	}))
+	// decklist and deck statistics, shown in tabs next to the image
+	statsPanel := NewStatsPanel(&state)
+	deckPanel := NewDeckPanel(&state)
+	collectionPanel := NewCollectionPanel(&state)
+	detailsPanel := container.NewStack()
+	rulingsPanel := NewRulingsPanel(&state)
+	tabs := container.NewAppTabs(container.NewTabItem("Details", detailsPanel), container.NewTabItem("Deck", deckPanel.container), container.NewTabItem("Stats", statsPanel.container), container.NewTabItem("Collection", collectionPanel.container), container.NewTabItem("Rulings", rulingsPanel.container))
+	tabsWidth := canvas.NewRectangle(color.Transparent) // keeps the tabs readable while they only show a button
+	tabsWidth.SetMinSize(fyne.NewSize(320, 0))
+
 	// Price Checking
 	priceContainer := container.NewCenter()
 	// price label
@@ -103,52 +221,262 @@ func main() {
 	priceLabel := widget.NewLabel("")
 	priceLabel.Bind(price)
 
-	// price Button
-	priceCheck := widget.NewButton("Check Price", func() {
-		p := GetCurrentDeckPrice(&state)
-		price.Set(strconv.FormatFloat(p, 'f', 2, 64) + "€")
+	// price strategy selection
+	strategyOptions := make([]string, 0, len(PriceStrategies))
+	for _, strategy := range PriceStrategies {
+		strategyOptions = append(strategyOptions, string(strategy))
+	}
+	strategySelect := widget.NewSelect(strategyOptions, nil)
+	strategySelect.SetSelected(string(state.priceStrategy))
+
+	// price Button, the price of the displayed deck is loaded in the background and replaces the button once it is known
+	var priceRequests atomic.Int64 // discards prices of decks that are no longer displayed
+	var priceCheck *widget.Button
+	loadPrice := func(waiting bool) {
+		request := priceRequests.Add(1)
 		priceContainer.RemoveAll()
-		priceContainer.Add(priceLabel)
-	})
+		if waiting { // the user asked for the price, show that it is on the way
+			price.Set("Checking price...")
+			priceContainer.Add(priceLabel)
+		} else {
+			priceContainer.Add(priceCheck)
+		}
+		prefetch := PrefetchCurrentDeck(&state)
+		if prefetch == nil {
+			return
+		}
+		go func() {
+			p := prefetch.WaitPrice()
+			RunOnUi(func() {
+				if priceRequests.Load() != request {
+					return
+				}
+				price.Set(p.String())
+				priceContainer.RemoveAll()
+				priceContainer.Add(priceLabel)
+			})
+		}()
+	}
+	priceCheck = widget.NewButton("Check Price", OnUi(func() {
+		loadPrice(true)
+	}))
 	priceContainer.Add(priceCheck)
+	// resetDeckViews hides everything that was shown for the previous deck and starts loading the new one
+	resetDeckViews := func() {
+		statsPanel.Reset()
+		deckPanel.Reset()
+		collectionPanel.Reset()
+		loadPrice(false)
+	}
+	// pinning the printing of a card of the deck changes the price of the pinned printing strategy
+	deckPanel.OnPrintings = func(card string) {
+		ShowPrintingsGallery(myApp, &state, card, func(gjson.Result) {
+			resetDeckViews()
+		})
+	}
+	// pricing only the cards missing from the collection
+	missingOnly := widget.NewCheck("Only missing cards", func(checked bool) {
+		RunOnUi(func() {
+			myApp.Preferences().SetBool(missingOnlyPreferenceKey, checked)
+			loadPrice(false)
+		})
+	})
+	missingOnly.Checked = myApp.Preferences().Bool(missingOnlyPreferenceKey)
+	strategySelect.OnChanged = func(selected string) { // a different strategy invalidates the displayed price
+		RunOnUi(func() {
+			state.priceStrategy = PriceStrategy(selected)
+			myApp.Preferences().SetString(priceStrategyPreferenceKey, selected)
+			loadPrice(false)
+		})
+	}
+
+	// deck variant selection
+	variantOptions := make([]string, 0, len(DeckVariants))
+	for _, variant := range DeckVariants {
+		variantOptions = append(variantOptions, string(variant))
+	}
+	variantSelect := widget.NewSelect(variantOptions, nil)
+	variantSelect.SetSelected(string(state.deckVariant))
+	variantSelect.OnChanged = func(selected string) { // another variant is another deck with another price
+		RunOnUi(func() {
+			if name, found := strings.CutPrefix(selected, SavedVariantPrefix); found {
+				for _, variant := range GetSavedVariants(myApp.Storage().RootURI().Path(), GetCurrentCommander(&state)) {
+					if variant.Name == name {
+						SetCurrentImportedDeck(&state, &ImportedDeck{Name: variant.Commanders[0], Commanders: variant.Commanders, DeckList: variant.DeckList, Variant: variant.Name})
+					}
+				}
+			} else {
+				if imported := GetCurrentImportedDeck(&state); imported != nil && imported.Variant != "" {
+					SetCurrentImportedDeck(&state, nil) // back to the deck of the deck provider
+				}
+				state.deckVariant = DeckVariant(selected)
+				myApp.Preferences().SetString(deckVariantPreferenceKey, selected)
+			}
+			resetDeckViews()
+		})
+	}
+
+	// refreshVariants offers the saved variants of the displayed commander next to the variants of the deck provider
+	refreshVariants := func() {
+		options := slices.Clone(variantOptions)
+		selected := string(state.deckVariant)
+		for _, variant := range GetSavedVariants(myApp.Storage().RootURI().Path(), GetCurrentCommander(&state)) {
+			options = append(options, SavedVariantPrefix+variant.Name)
+		}
+		if imported := GetCurrentImportedDeck(&state); imported != nil && imported.Variant != "" {
+			selected = SavedVariantPrefix + imported.Variant
+		}
+		variantSelect.Options = options
+		variantSelect.Selected = selected
+		variantSelect.Refresh()
+	}
+
+	// deck theme selection, the options are filled once the themes of the commander are known
+	themeSelect := widget.NewSelect([]string{allThemesLabel}, nil)
+	themeSelect.SetSelected(allThemesLabel)
+	themeSelect.OnChanged = func(selected string) { // another theme is another deck with another price
+		RunOnUi(func() {
+			slug := ""
+			for _, t := range GetThemes(&state, GetCurrentCommander(&state)) { // the themes are cached once they are offered
+				if t.Label() == selected {
+					slug = t.Slug
+				}
+			}
+			SetCurrentTheme(&state, slug)
+			resetDeckViews()
+		})
+	}
+	var themeRequests atomic.Int64 // discards themes of commanders that are no longer displayed
+	refreshThemes := func() {
+		request := themeRequests.Add(1)
+		current := GetCurrentTheme(&state)
+		themeSelect.Options = []string{allThemesLabel}
+		themeSelect.Selected = allThemesLabel
+		themeSelect.Refresh()
+		commander := GetCurrentCommander(&state)
+		go func() {
+			themes := GetThemes(&state, commander)
+			RunOnUi(func() {
+				if themeRequests.Load() != request {
+					return
+				}
+				options := []string{allThemesLabel}
+				selected := allThemesLabel
+				for _, t := range themes {
+					options = append(options, t.Label())
+					if t.Slug == current {
+						selected = t.Label()
+					}
+				}
+				themeSelect.Options = options
+				themeSelect.Selected = selected
+				themeSelect.Refresh()
+			})
+		}()
+	}
+
+	// coverage of the displayed deck by the collection, only known for commanders drawn for their coverage
+	coverageLabel := widget.NewLabel("")
+	// showCommander displays a commander and resets everything that belongs to the previous one
+	showCommander := func(image fyne.Resource) {
+		clickableImage.image.Resource = image
+		clickableImage.image.Refresh()
+		refreshThemes()
+		refreshVariants()
+		resetDeckViews()
+		detailsPanel.Objects = []fyne.CanvasObject{container.NewVScroll(NewDetailsView(GetCurrentCard(&state)))}
+		detailsPanel.Refresh()
+		rulingsPanel.Reset()
+		coverageLabel.SetText("")
+		if coverage := GetCurrentCoverage(&state); coverage != nil {
+			coverageLabel.SetText(coverage.String())
+		}
+	}
 
 	// Buttons
 	// Previous
-	previous := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+	previous := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), OnUi(func() {
 		image := GetPreviousCommanderData(&state)
 		if image != nil { // if there is a previous commander
-			clickableImage.image.Resource = image
-			clickableImage.image.Refresh()
-			priceContainer.RemoveAll()
-			priceContainer.Add(priceCheck)
+			showCommander(image)
 		}
 
-	})
+	}))
 	// Get Decklist
-	get := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
-		deckList := GetCurrentDeckList(&state)
-		clipboard.Write(clipboard.FmtText, []byte(deckList))
+	get := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), OnUi(func() {
+		source, ok := currentDeckSource(&state)
+		if !ok {
+			return
+		}
+		go func() { // the decklist may still be loading, the window keeps working meanwhile
+			deckList := source.deckList(&state)
+			RunOnUi(func() { CopyToClipboard(w, deckList, "decklist.txt") })
+		}()
+	}))
+	// Export
+	var export *widget.Button
+	export = widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {
+		ShowExportMenu(w, &state, export)
 	})
+	// draw the next random commander in the background while the current one is looked at
+	prefetchNext := func() {
+		if state.backSteps == 0 && minCoverage() == 0 { // commanders drawn for their coverage are queued already
+			PrefetchNextCommander(&state, GetSelectedChoices(choiceColorMap), searchQuery.Text)
+		}
+	}
 	//Next
-	next := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
-		image := GetNextCommanderData(&state, GetSelectedChoices(choiceColorMap), searchQuery.Text)
-		clickableImage.image.Resource = image
-		clickableImage.image.Refresh()
-		priceContainer.RemoveAll()
-		priceContainer.Add(priceCheck)
-	})
+	var next *widget.Button
+	searchLabel := widget.NewLabel("") // the progress of drawing a commander within the budget or coverage
+	// drawNext shows the next commander, Next is disabled while a new one is drawn in the background
+	drawNext := func(failed func(err error)) {
+		next.Disable()
+		searchLabel.SetText("Searching...")
+		GetNextCommanderData(&state, GetSelectedChoices(choiceColorMap), searchQuery.Text, ParseBudget(budget.Text), minCoverage(), searchLabel.SetText, func(image fyne.Resource, err error) {
+			next.Enable()
+			searchLabel.SetText("")
+			if err != nil {
+				failed(err)
+				return
+			}
+			showCommander(image)
+			prefetchNext()
+		})
+	}
+	next = widget.NewButtonWithIcon("", theme.NavigateNextIcon(), OnUi(func() {
+		drawNext(func(err error) { dialog.ShowError(err, w) })
+	}))
 
-	buttons := container.NewCenter(container.NewHBox(previous, get, next))
-	vBox := container.NewVBox(searchQuery, clickableImage, container.NewCenter(choices), buttons, priceContainer)
+	// Edit
+	edit := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), OnUi(func() {
+		ShowDeckEditor(myApp, &state, func() {
+			refreshVariants()
+			resetDeckViews()
+		})
+	}))
+
+	// Printings
+	printings := widget.NewButtonWithIcon("", theme.GridIcon(), OnUi(func() {
+		ShowCommanderPrintings(myApp, &state, showCommander)
+	}))
+
+	// Import
+	importDeck := widget.NewButtonWithIcon("", theme.UploadIcon(), OnUi(func() {
+		ShowImportDialog(w, &state, showCommander)
+	}))
+
+	buttons := container.NewCenter(container.NewHBox(themeSelect, variantSelect, previous, get, edit, printings, export, next))
+	vBox := container.NewVBox(container.NewBorder(nil, nil, nil, container.NewHBox(importDeck, settings), searchQuery), container.NewBorder(nil, nil, nil, container.NewStack(tabsWidth, tabs), clickableImage), container.NewCenter(container.NewHBox(coverageLabel, searchLabel)), container.NewCenter(container.NewHBox(choices, budget, coverage)), buttons, container.NewCenter(container.NewHBox(strategySelect, missingOnly, priceContainer)))
 	w.SetContent(vBox)
 	w.SetIcon(resourceIconPng)
 
 	// Load initial state
-	// pull any first commander image
-	image := GetNextCommanderData(&state, GetSelectedChoices(choiceColorMap), searchQuery.Text) // get any first commander (nothing selected)
-	// Set the Image inside the View and Refresh
-	clickableImage.image.Resource = image
-	clickableImage.image.Refresh()
+	// pull any first commander image, it is shown once it is drawn
+	RunOnUi(func() {
+		drawNext(func(error) {
+			showCommander(resourcePlaceholderPng)
+			prefetchNext()
+		})
+	})
 
 	w.ShowAndRun()
 }
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"image/color"
+	"strings"
+)
+
+// manaSymbolResources are the bundled icons of the colored mana symbols
+var manaSymbolResources = map[string]*fyne.StaticResource{
+	"W": resourceWSvg,
+	"U": resourceUSvg,
+	"B": resourceBSvg,
+	"R": resourceRSvg,
+	"G": resourceGSvg,
+	"C": resourceCSvg,
+}
+
+// genericSymbolColor is the background of symbols without a color, e.g. {2}, {X} or {T}
+var genericSymbolColor = color.NRGBA{R: 204, G: 194, B: 192, A: 255}
+
+// ManaToken is a piece of a text with mana symbols, either plain text or the content of a symbol like "W" or "2/W"
+type ManaToken struct {
+	Text   string
+	Symbol bool
+}
+
+// ParseManaSymbols
+// Splits a text into plain text and the mana symbols inside curly braces
+// Params: the text, e.g. a mana cost or an oracle text
+// Returns: the tokens in the order of the text
+func ParseManaSymbols(text string) []ManaToken {
+	tokens := make([]ManaToken, 0)
+	start := 0
+	for _, match := range manaSymbolRegex.FindAllStringSubmatchIndex(text, -1) {
+		if match[0] > start {
+			tokens = append(tokens, ManaToken{Text: text[start:match[0]]})
+		}
+		tokens = append(tokens, ManaToken{Text: text[match[2]:match[3]], Symbol: true})
+		start = match[1]
+	}
+	if start < len(text) {
+		tokens = append(tokens, ManaToken{Text: text[start:]})
+	}
+	return tokens
+}
+
+// NewManaSymbol
+// Draws a mana symbol as a colored circle with the bundled icon of its color or its text, hybrid symbols get a circle for each half
+// Params: the content of the symbol without braces, e.g. "W", "2/W" or "T"
+// Returns: the symbol
+func NewManaSymbol(symbol string) fyne.CanvasObject {
+	size := theme.TextSize() + 2
+	parts := container.NewHBox()
+	for _, part := range strings.Split(symbol, "/") {
+		fill := color.Color(genericSymbolColor)
+		var icon fyne.CanvasObject
+		if resource, ok := manaSymbolResources[part]; ok {
+			fill = manaColors[part]
+			if part == "W" { // the white icon would vanish on the pale background
+				resource = fyne.NewStaticResource("w-dark.svg", bytes.ReplaceAll(resource.StaticContent, []byte(`"white"`), []byte(`"#333333"`)))
+			}
+			image := canvas.NewImageFromResource(resource)
+			image.FillMode = canvas.ImageFillContain
+			image.SetMinSize(fyne.NewSize(size-4, size-4))
+			icon = container.NewCenter(image)
+		} else {
+			text := canvas.NewText(part, color.Black)
+			text.TextSize = theme.TextSize() - 2
+			text.TextStyle = fyne.TextStyle{Bold: true}
+			icon = container.NewCenter(text)
+		}
+		circle := canvas.NewCircle(fill)
+		background := newBar(color.Transparent, size, size)
+		parts.Add(container.NewStack(background, circle, icon))
+	}
+	return parts
+}
+
+// NewManaText
+// Lays out a text with inline mana symbols, the words wrap at the given width.
+// Line breaks of the text start a new paragraph.
+// Params: the text, its style and the width to wrap at
+// Returns: the text with its symbols
+func NewManaText(text string, style fyne.TextStyle, width float32) fyne.CanvasObject {
+	paragraphs := container.NewVBox()
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := container.New(&flowLayout{width: width, spacing: fyne.MeasureText(" ", theme.TextSize(), style).Width})
+		for _, token := range ParseManaSymbols(paragraph) {
+			if token.Symbol {
+				words.Add(NewManaSymbol(token.Text))
+				continue
+			}
+			for _, word := range strings.Fields(token.Text) {
+				label := canvas.NewText(word, theme.ForegroundColor())
+				label.TextStyle = style
+				words.Add(label)
+			}
+		}
+		paragraphs.Add(words)
+	}
+	return paragraphs
+}
+
+// flowLayout places objects next to each other like words and wraps them into the next line once the width is used up
+type flowLayout struct {
+	width   float32 // the width the minimum size is calculated for, the layout wraps at the actual width
+	spacing float32
+}
+
+func (l *flowLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	l.place(objects, size.Width, true)
+}
+
+func (l *flowLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return l.place(objects, l.width, false)
+}
+
+// place calculates the position of each object, moving them if move is set
+// Returns: the size of the placed objects
+func (l *flowLayout) place(objects []fyne.CanvasObject, width float32, move bool) fyne.Size {
+	var x, y, lineHeight, widest float32
+	for _, object := range objects {
+		size := object.MinSize()
+		if x > 0 && x+size.Width > width {
+			x, y, lineHeight = 0, y+lineHeight, 0
+		}
+		if move {
+			object.Resize(size)
+			object.Move(fyne.NewPos(x, y))
+		}
+		x += size.Width + l.spacing
+		widest = max(widest, x-l.spacing)
+		lineHeight = max(lineHeight, size.Height)
+	}
+	return fyne.NewSize(widest, y+lineHeight)
+}
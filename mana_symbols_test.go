@@ -0,0 +1,45 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseManaSymbols(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		tokens []ManaToken
+	}{
+		{name: "empty", text: "", tokens: []ManaToken{}},
+		{name: "plain text", text: "Flying", tokens: []ManaToken{{Text: "Flying"}}},
+		{
+			name:   "mana cost",
+			text:   "{2}{W}{U}",
+			tokens: []ManaToken{{Text: "2", Symbol: true}, {Text: "W", Symbol: true}, {Text: "U", Symbol: true}},
+		},
+		{
+			name:   "hybrid symbols",
+			text:   "{2/W}{G/U}{B/P}",
+			tokens: []ManaToken{{Text: "2/W", Symbol: true}, {Text: "G/U", Symbol: true}, {Text: "B/P", Symbol: true}},
+		},
+		{
+			name:   "text before and after a symbol",
+			text:   "Add {C}{C}. Activate only once.",
+			tokens: []ManaToken{{Text: "Add "}, {Text: "C", Symbol: true}, {Text: "C", Symbol: true}, {Text: ". Activate only once."}},
+		},
+		{
+			name:   "text between symbols",
+			text:   "{T}: Add {G}",
+			tokens: []ManaToken{{Text: "T", Symbol: true}, {Text: ": Add "}, {Text: "G", Symbol: true}},
+		},
+		{name: "unclosed brace", text: "{W", tokens: []ManaToken{{Text: "{W"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if tokens := ParseManaSymbols(test.text); !slices.Equal(tokens, test.tokens) {
+				t.Errorf("ParseManaSymbols(%q) = %v, want %v", test.text, tokens, test.tokens)
+			}
+		})
+	}
+}
@@ -4,73 +4,59 @@ import (
 	"errors"
 	"fmt"
 	"github.com/tidwall/gjson"
-	"golang.org/x/text/runes"
-	"golang.org/x/text/transform"
-	"golang.org/x/text/unicode/norm"
 	"io"
 	"net/http"
 	"net/url"
-	"regexp"
 	"strconv"
 	"strings"
-	"unicode"
 )
 
-var EdhrecBaseUrl = "https://edhrec.com"
 var NumberOfGoRoutines = 2
+var MaxBudgetDraws = 15 // number of commanders drawn before giving up on finding one within the budget
 
 // GetCommanderFromScryfall
 // Selects a random commander depending on the input constraints and fetches an image and for said commander
 // Params: An Array of strings containing all currently selected color checkboxes (and the "Exact" checkbox) from the UI
-// Returns: A Tuple of 2 strings, the first of which being the formatted name of the commander and the second being the link to its card image
-func GetCommanderFromScryfall(selectedColors []string, searchQuery string) (string, string) {
+// Returns: A Tuple of 3 strings, the formatted name of the commander, the link to its card image and the scryfall card object as json
+func GetCommanderFromScryfall(selectedColors []string, searchQuery string) (string, string, string) {
 	var query = BuildScryfallCommanderQuery(selectedColors, searchQuery)
 	fmt.Println("Retrieving Commander with Query: " + query)
 	commanderData, err := GetScryfallCommanderData(query)
-	if err != nil {
-		return "", "" // no commander found -> return empty name and placeholder pic
+	if err != nil || gjson.Get(commanderData, "object").String() != "card" {
+		return "", "", "" // no commander found -> return empty name and placeholder pic
 	} else {
 		cardName, imageUri := ParseScryfallData(commanderData)
-		return cardName, imageUri
+		return cardName, imageUri, commanderData
 	}
 }
 
 // GetEDHRecAvgDecklist
 // Retrieves the average decklist for a given commander name from EDHRec.com
-// Params: The name of the commander the decklist shall be retrieved for
+// Params: The name of the commander the decklist shall be retrieved for, the slug of a theme ("" for all decks) and the variant of the average deck
 // Returns: a Tuple containing a string, representing the average decklist for the commander and an error that is nil unless the retrieval was unsuccessful
-func GetEDHRecAvgDecklist(commander string) (string, error) {
-	avgDeckEndpoint := EdhrecBaseUrl + "/_next/data/" + GetBuildId() + "/average-decks/" + commander + ".json?commanderName=" + commander // 7-TtnLfoAX_AgebfCokAf
-	fmt.Println("Retrieving Deck from: " + avgDeckEndpoint)
-	pageResp, err := http.Get(avgDeckEndpoint)
+func GetEDHRecAvgDecklist(commander string, theme string, variant DeckVariant) (string, error) {
+	dataJson, err := GetEDHRecPageData(GetAverageDeckPath(commander, theme, variant), commander)
 	if err != nil {
 		return "", err
 	} else {
-		pageBytes, err := io.ReadAll(pageResp.Body)
-		defer pageResp.Body.Close()
+		numDecksValue := gjson.Get(dataJson, "num_decks").String()
+		if numDecksValue == "" { // if num_decks does not exist
+			deckJson := gjson.Get(dataJson, "deck").String()
+			deckJson = strings.ReplaceAll(deckJson, "\",\"", "\n")
+			if len(deckJson) > 4 {
+				fmt.Println("Deck copied!")
+				return deckJson[2 : len(deckJson)-2], nil
+			} else {
+				return "", errors.New("the deck inside the response was empty")
+			}
+		}
+		_, err := strconv.Atoi(numDecksValue)
 		if err != nil {
+			fmt.Println("ERROR" + err.Error())
 			return "", err
 		} else {
-			pageJson := string(pageBytes)
-			numDecksValue := gjson.Get(pageJson, "pageProps.data.num_decks").String()
-			if numDecksValue == "" { // if num_decks does not exist
-				deckJson := gjson.Get(pageJson, "pageProps.data.deck").String()
-				deckJson = strings.ReplaceAll(deckJson, "\",\"", "\n")
-				if len(deckJson) > 4 {
-					fmt.Println("Deck copied!")
-					return deckJson[2 : len(deckJson)-2], nil
-				} else {
-					return "", errors.New("the deck inside the response was empty")
-				}
-			}
-			_, err := strconv.Atoi(numDecksValue)
-			if err != nil {
-				fmt.Println("ERROR" + err.Error())
-				return "", err
-			} else {
-				fmt.Println("NO DECKS")
-				return "", errors.New("no decks found for commander: " + commander)
-			}
+			fmt.Println("NO DECKS")
+			return "", errors.New("no decks found for commander: " + commander)
 		}
 	}
 }
@@ -105,29 +91,12 @@ func BuildScryfallCommanderQuery(selectedColors []string, searchQuery string) st
 func ParseScryfallData(jsonData string) (string, string) {
 	// get the cards name
 	var cardName = gjson.Get(jsonData, "name").String()
-	// format the card name to EDHREC URL format
 	fmt.Println("Retrieved Commander: " + cardName)
-	var replacer = *strings.NewReplacer(
-		" ", "-",
-		",", "",
-		"'", "",
-		"&", "",
-		".", "")
-
-	var formattedCardName = strings.ToLower(replacer.Replace(cardName))
-	transformer := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
-	res, _, err := transform.String(transformer, formattedCardName)
-	if err == nil {
-		formattedCardName = res
-	}
-	firstCardName, _, found := strings.Cut(formattedCardName, "-//")
+	// format the card name to EDHREC URL format
+	formattedCardName := EdhrecSlug(cardName)
 	imageUri := gjson.Get(jsonData, "image_uris.border_crop").String()
-	if found { // if the card is double faced we get only the first card image
-		formattedCardName = firstCardName
-		if imageUri == "" {
-			imageUri = gjson.Get(jsonData, "card_faces.0.image_uris.border_crop").String()
-
-		}
+	if imageUri == "" { // if the card is double faced we get only the first card image
+		imageUri = gjson.Get(jsonData, "card_faces.0.image_uris.border_crop").String()
 	}
 
 	return formattedCardName, imageUri
@@ -152,82 +121,6 @@ func GetScryfallCommanderData(query string) (string, error) {
 	}
 }
 
-// GetBuildId
-// Creates a valid buildId needed for EDHRec queries
-// Param: None
-// Return: A valid buildId as a String
-func GetBuildId() string {
-	response, err := http.Get(EdhrecBaseUrl)
-	if err != nil {
-		return "7-TtnLfoAX_AgebfCokAf"
-	} else {
-		body, err := io.ReadAll(response.Body) // read the data to a byte array
-		defer response.Body.Close()            // close the Reader once this function returns
-		if err != nil {                        // if we cant read the response, return the error
-			return "7-TtnLfoAX_AgebfCokAf"
-		} else { // if we read the response successfully
-			scriptBlockRegex := regexp.MustCompile("<script id=\"__NEXT_DATA__\" type=\"application/json\">(.*)</script>")
-			match := scriptBlockRegex.Find(body)
-			propsData := string(match)
-			id := gjson.Get(propsData, "buildId").String()
-			fmt.Println("ID EQUALS: " + id)
-			return id
-		}
-	}
-}
-
-// GetScryfallPricingData
-// Build a json object of card identifiers for a decklist and retrieve pricing information for the entire deck
-// params: a decklist as an [] string with every entry being formatted as "1 <CardName>"
-// returns the sum of prices of the cheapest, most recent printings of all cards in the request in euro
-func GetScryfallPricingData(deck []string, numberOfGoRoutines int) float64 {
-	//set up channel
-	result := make(chan float64, numberOfGoRoutines)
-	sum := 0.0
-	parts := splitDeckListIntoChunks(deck, numberOfGoRoutines)
-	if parts == nil {
-		return 0.0
-	} else {
-		for i := range numberOfGoRoutines {
-			go func(i int) {
-				list := parts[i]
-				// build json array
-				jsonArray := "{\"identifiers\":["
-				for _, card := range list {
-					name := strings.SplitN(card, " ", 2)[1]
-					jsonArray += "{\"name\":\"" + name + "\"},"
-				}
-				jsonArray = jsonArray[:len(jsonArray)-1] + "]}"
-
-				// fetch prices for list
-				resp, err := http.Post("https://api.scryfall.com/cards/collection/", "application/json", strings.NewReader(jsonArray))
-				if err != nil {
-					result <- 0.0
-				} else {
-					body, err := io.ReadAll(resp.Body)
-					defer resp.Body.Close()
-					if err != nil {
-						result <- 0.0
-					} else {
-						data := string(body)
-						prices := gjson.Get(data, "data.#.prices.eur")
-						sum := 0.0
-						for _, p := range prices.Array() {
-							sum += p.Float()
-						}
-						result <- sum
-					}
-				}
-			}(i)
-		}
-		for range numberOfGoRoutines {
-			sum += <-result
-		}
-		fmt.Println("Price:" + strconv.FormatFloat(sum, 'f', 2, 64))
-		return sum
-	}
-}
-
 // SplitSlice splits a decklist in `numberOfChunks` slices.
 // Each slice is, at most, one element bigger than any other slice.
 // If the input array is nil, or empty, the function returns nil.
@@ -266,13 +159,21 @@ func splitDeckListIntoChunks(decklist []string, numberOfChunks int) [][]string {
 	return result
 }
 
-// GetAlternateCardFace
-// retrives the alternate card face uri for the card if it is a flip card, otherwise return nil
-func GetAlternateCardFace(name string, index int) string {
-	resp, err := GetScryfallCommanderData("https://api.scryfall.com/cards/named?fuzzy=" + url.QueryEscape(name))
+// GetScryfallAutocomplete
+// Retrieves the card names scryfall suggests for a partial name
+// Params: the partial name, scryfall needs at least two characters
+// Returns: up to 20 card names and an error if scryfall could not be reached
+func GetScryfallAutocomplete(partialName string) ([]string, error) {
+	if len([]rune(strings.TrimSpace(partialName))) < 2 {
+		return nil, nil
+	}
+	response, err := GetScryfallCommanderData("https://api.scryfall.com/cards/autocomplete?q=" + url.QueryEscape(partialName))
 	if err != nil {
-		return ""
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, name := range gjson.Get(response, "data").Array() {
+		names = append(names, name.String())
 	}
-	println("card_faces." + strconv.Itoa(index) + ".image_uris.border_crop")
-	return gjson.Get(resp, "card_faces."+strconv.Itoa(index)+".image_uris.border_crop").String()
+	return names, nil
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"slices"
+	"strings"
+)
+
+// commanderPrefetch is a random commander that is drawn together with its image in the background, before the user asks for it
+type commanderPrefetch struct {
+	key      string        // the colors and query the commander was drawn for
+	done     chan struct{} // closed once the commander and its image are loaded
+	name     string
+	imageUri string
+	card     string
+	image    fyne.Resource
+}
+
+// prefetchKey identifies the colors and the query a commander is drawn for, the order of the colors does not matter
+func prefetchKey(selected []string, query string) string {
+	sortedColors := slices.Clone(selected)
+	slices.Sort(sortedColors)
+	return strings.Join(sortedColors, "") + "|" + query
+}
+
+// PrefetchNextCommander
+// Draws the next random commander and loads its image in the background, so the next commander can be shown instantly.
+// Nothing is drawn if a commander for the same colors and query is already waiting.
+// Params: the session state, the selected colors and the search query
+func PrefetchNextCommander(state *SessionState, selected []string, query string) {
+	key := prefetchKey(selected, query)
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	if state.prefetch != nil && state.prefetch.key == key {
+		return
+	}
+	prefetch := &commanderPrefetch{key: key, done: make(chan struct{})}
+	state.prefetch = prefetch
+	go func() {
+		defer close(prefetch.done)
+		prefetch.name, prefetch.imageUri, prefetch.card = GetCommanderFromScryfall(selected, query)
+		if prefetch.name == "" {
+			return
+		}
+		prefetch.image = GetImageResource(prefetch.imageUri)
+	}()
+}
+
+// takePrefetchedCommander
+// Takes the prefetched commander, waiting for it if it is still loading. A commander drawn for other colors or another query is discarded.
+// Params: the session state, the selected colors, the search query and the commanders of the history
+// Returns: the name, image uri, card json and image of the commander and false if no usable commander was prefetched
+func takePrefetchedCommander(state *SessionState, selected []string, query string, drawn []string) (string, string, string, fyne.Resource, bool) {
+	state.mutex.Lock()
+	prefetch := state.prefetch
+	state.prefetch = nil
+	state.mutex.Unlock()
+	if prefetch == nil || prefetch.key != prefetchKey(selected, query) {
+		return "", "", "", nil, false
+	}
+	<-prefetch.done
+	if prefetch.name == "" || slices.Contains(drawn, prefetch.name) {
+		return "", "", "", nil, false
+	}
+	return prefetch.name, prefetch.imageUri, prefetch.card, prefetch.image, true
+}
+
+// DeckPrefetch is the decklist and the price of a history entry, loaded in the background as soon as the commander is displayed.
+// The decklist is known long before the price, pricing may take hundreds of requests to scryfall.
+type DeckPrefetch struct {
+	key       string        // the deck and the pricing options it was loaded for
+	deckDone  chan struct{} // closed once the decklist is loaded
+	priceDone chan struct{} // closed once the price is loaded
+	deckList  string
+	price     DeckPrice
+}
+
+// WaitDeckList blocks until the decklist is loaded, without waiting for the price
+func (p *DeckPrefetch) WaitDeckList() string {
+	<-p.deckDone
+	return p.deckList
+}
+
+// WaitPrice blocks until the price is loaded, it must never be called on the UI thread
+func (p *DeckPrefetch) WaitPrice() DeckPrice {
+	<-p.priceDone
+	return p.price
+}
+
+// PrefetchCurrentDeck
+// Loads the decklist and the price of the displayed commander in the background and stores them in its history entry.
+// A prefetch that is still valid for the deck and the pricing options is reused.
+// Params: the session state
+// Returns: the DeckPrefetch, nil if no commander is displayed
+func PrefetchCurrentDeck(state *SessionState) *DeckPrefetch {
+	source, ok := currentDeckSource(state)
+	if !ok {
+		return nil
+	}
+	if source.prefetch != nil {
+		return source.prefetch
+	}
+	prefetch := &DeckPrefetch{key: source.key(), deckDone: make(chan struct{}), priceDone: make(chan struct{})}
+	state.mutex.Lock()
+	state.prevCommanderDeckPrefetches[state.commanderCount-state.backSteps] = prefetch
+	state.mutex.Unlock()
+	go func() {
+		prefetch.deckList = source.deckList(state)
+		close(prefetch.deckDone)
+		prefetch.price = source.price(state)
+		close(prefetch.priceDone)
+	}()
+	return prefetch
+}
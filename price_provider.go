@@ -0,0 +1,455 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"github.com/tidwall/gjson"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PriceProvider is a source of card prices
+type PriceProvider interface {
+	// Name returns the name of the provider as it is shown in the settings and next to deck prices
+	Name() string
+	// GetPrintings returns the printings of the given cards keyed by the requested names.
+	// Depending on the strategy of the options these are all printings of a card or only the newest or pinned one.
+	// Cards the provider does not know are missing from the result.
+	GetPrintings(names []string, options PricingOptions) (map[string][]CardPrinting, error)
+}
+
+const (
+	ScryfallPriceProviderName     = "Scryfall"
+	ScryfallBulkPriceProviderName = "Scryfall bulk file"
+	CardmarketPriceProviderName   = "Cardmarket price guide"
+	priceProviderPreferenceKey    = "priceProvider"
+	scryfallBulkFilePreferenceKey = "scryfallBulkFile"
+	priceGuideFilePreferenceKey   = "cardmarketPriceGuideFile"
+)
+
+// PriceProviderNames contains the names of all providers in the order they are offered inside the settings
+var PriceProviderNames = []string{ScryfallPriceProviderName, ScryfallBulkPriceProviderName, CardmarketPriceProviderName}
+
+// scryfallCollectionLimit is the maximum amount of identifiers scryfall accepts in a single /cards/collection request
+const scryfallCollectionLimit = 75
+
+// GetSelectedPriceProvider
+// Creates the price provider selected in the settings
+// Params: the preferences of the app
+// Returns: the selected PriceProvider, live scryfall prices if nothing else is selected
+func GetSelectedPriceProvider(preferences fyne.Preferences) PriceProvider {
+	var printings PriceProvider = ScryfallPriceProvider{NumberOfGoRoutines: NumberOfGoRoutines}
+	if bulkFile := preferences.String(scryfallBulkFilePreferenceKey); bulkFile != "" {
+		printings = ScryfallBulkPriceProvider{Path: bulkFile}
+	}
+	switch preferences.String(priceProviderPreferenceKey) {
+	case ScryfallBulkPriceProviderName:
+		return ScryfallBulkPriceProvider{Path: preferences.String(scryfallBulkFilePreferenceKey)}
+	case CardmarketPriceProviderName: // printings come from the bulk file if there is one so cardmarket prices work offline
+		return CardmarketPriceProvider{Path: preferences.String(priceGuideFilePreferenceKey), Printings: printings}
+	default:
+		return ScryfallPriceProvider{NumberOfGoRoutines: NumberOfGoRoutines}
+	}
+}
+
+// wantsAllPrintings
+// Checks if a strategy compares the prices of all printings of a card
+func wantsAllPrintings(strategy PriceStrategy) bool {
+	return strategy == PriceStrategyCheapest || strategy == PriceStrategyMedian
+}
+
+// ScryfallPriceProvider provides the live prices of the scryfall api
+type ScryfallPriceProvider struct {
+	NumberOfGoRoutines int // number of parallel requests to /cards/collection
+}
+
+func (p ScryfallPriceProvider) Name() string {
+	return ScryfallPriceProviderName
+}
+
+func (p ScryfallPriceProvider) GetPrintings(names []string, options PricingOptions) (map[string][]CardPrinting, error) {
+	return GetScryfallPricingData(names, p.NumberOfGoRoutines, options)
+}
+
+// GetScryfallPricingData
+// Build a json object of card identifiers for a list of cards and retrieve their printings from scryfall
+// params: the card names, the number of parallel requests and the pricing options
+// returns the printings of the cards keyed by the requested names and an error if none of the requests succeeded
+func GetScryfallPricingData(names []string, numberOfGoRoutines int, options PricingOptions) (map[string][]CardPrinting, error) {
+	// scryfall only accepts a limited number of identifiers per request
+	numberOfChunks := max(numberOfGoRoutines, (len(names)+scryfallCollectionLimit-1)/scryfallCollectionLimit)
+	printings := make(map[string][]CardPrinting)
+	parts := splitDeckListIntoChunks(names, numberOfChunks)
+	if parts == nil {
+		return printings, nil
+	}
+	//set up channel
+	type chunkResult struct {
+		printings map[string][]CardPrinting
+		err       error
+	}
+	result := make(chan chunkResult, numberOfChunks)
+	for i := range numberOfChunks {
+		go func(list []string) {
+			partial := make(map[string][]CardPrinting)
+			if len(list) == 0 {
+				result <- chunkResult{partial, nil}
+				return
+			}
+			// fetch prices for list
+			cards, err := GetScryfallCollection(list, options)
+			if err != nil {
+				result <- chunkResult{nil, err}
+				return
+			}
+			for name, card := range cards {
+				partial[name] = getScryfallCardPrintings(card, options.Strategy)
+			}
+			result <- chunkResult{partial, nil}
+		}(parts[i])
+	}
+	errs := make([]error, 0)
+	for range numberOfChunks {
+		partial := <-result
+		if partial.err != nil {
+			errs = append(errs, partial.err)
+		}
+		for name, cardPrintings := range partial.printings {
+			printings[name] = cardPrintings
+		}
+	}
+	if len(errs) == numberOfChunks {
+		return nil, errors.Join(errs...)
+	}
+	return printings, nil
+}
+
+// GetScryfallCollection
+// Retrieves the card objects for a list of at most 75 card names with a single scryfall /cards/collection request
+// Params: the card names and the pricing options, pinned printings are requested if the strategy asks for them
+// Returns: the card objects keyed by the requested names and an error if the request failed, cards scryfall does not know are missing
+func GetScryfallCollection(names []string, options PricingOptions) (map[string]gjson.Result, error) {
+	requested := make(map[string]string) // requested names keyed by their lower case spelling
+	for _, name := range names {
+		requested[strings.ToLower(name)] = name
+	}
+	resp, err := http.Post("https://api.scryfall.com/cards/collection", "application/json", strings.NewReader(buildCollectionIdentifiers(names, options)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	data := string(body)
+	if gjson.Get(data, "object").String() == "error" {
+		return nil, fmt.Errorf("scryfall: %s", gjson.Get(data, "details").String())
+	}
+	cards := make(map[string]gjson.Result)
+	for _, card := range gjson.Get(data, "data").Array() {
+		for _, cardName := range []string{card.Get("name").String(), card.Get("card_faces.0.name").String()} {
+			if name, ok := requested[strings.ToLower(cardName)]; ok {
+				cards[name] = card
+				break
+			}
+		}
+	}
+	return cards, nil
+}
+
+// getScryfallCardPrintings
+// Collects the printings of a card that are relevant for the given strategy
+// Params: the card object returned by /cards/collection and the strategy
+// Returns: the printings that shall be used to price the card
+func getScryfallCardPrintings(card gjson.Result, strategy PriceStrategy) []CardPrinting {
+	if !wantsAllPrintings(strategy) {
+		return []CardPrinting{ParseCardPrinting(card)}
+	}
+	prints, err := GetScryfallList(card.Get("prints_search_uri").String())
+	if err != nil { // fall back to the printing we already know
+		fmt.Println("ERROR: " + err.Error())
+		return []CardPrinting{ParseCardPrinting(card)}
+	}
+	printings := make([]CardPrinting, 0, len(prints))
+	for _, p := range prints {
+		printings = append(printings, ParseCardPrinting(p))
+	}
+	return printings
+}
+
+// buildCollectionIdentifiers
+// Builds the body of a scryfall /cards/collection request, using pinned printings where the strategy asks for them
+// Params: the card names of the request and the pricing options
+// Returns: the json body as a string
+func buildCollectionIdentifiers(names []string, options PricingOptions) string {
+	identifiers := make([]map[string]string, 0, len(names))
+	for _, name := range names {
+		pin, pinned := options.Pins[name]
+		if options.Strategy == PriceStrategyPinned && pinned {
+			identifiers = append(identifiers, map[string]string{"set": pin.Set, "collector_number": pin.CollectorNumber})
+		} else {
+			identifiers = append(identifiers, map[string]string{"name": name})
+		}
+	}
+	body, _ := json.Marshal(map[string]any{"identifiers": identifiers})
+	return string(body)
+}
+
+// ScryfallBulkPriceProvider provides the prices of a scryfall "Default Cards" or "All Cards" bulk file on disk
+type ScryfallBulkPriceProvider struct {
+	Path string
+}
+
+// bulkPrintingsCache holds the printings of the last bulk file that was read, reading the file takes several seconds
+var bulkPrintingsCache struct {
+	sync.Mutex
+	path      string
+	modified  time.Time
+	printings map[string][]CardPrinting // printings keyed by the lower case card name and front face name, newest first
+}
+
+func (p ScryfallBulkPriceProvider) Name() string {
+	return ScryfallBulkPriceProviderName
+}
+
+func (p ScryfallBulkPriceProvider) GetPrintings(names []string, options PricingOptions) (map[string][]CardPrinting, error) {
+	index, err := loadBulkPrintings(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	printings := make(map[string][]CardPrinting)
+	for _, name := range names {
+		cardPrintings := index[strings.ToLower(name)]
+		if len(cardPrintings) == 0 {
+			continue
+		}
+		pin, pinned := options.Pins[name]
+		switch {
+		case wantsAllPrintings(options.Strategy):
+			printings[name] = cardPrintings
+		case options.Strategy == PriceStrategyPinned && pinned:
+			i := slices.IndexFunc(cardPrintings, func(c CardPrinting) bool {
+				return c.Set == pin.Set && c.CollectorNumber == pin.CollectorNumber
+			})
+			if i >= 0 {
+				printings[name] = cardPrintings[i : i+1]
+			} else {
+				printings[name] = cardPrintings[:1]
+			}
+		default:
+			printings[name] = cardPrintings[:1]
+		}
+	}
+	return printings, nil
+}
+
+// loadBulkPrintings
+// Reads the paper printings of a scryfall bulk file, the result is cached until the file changes
+// Params: the path of the bulk file
+// Returns: the printings keyed by the lower case card name and front face name, newest first, and an error if the file could not be read
+func loadBulkPrintings(path string) (map[string][]CardPrinting, error) {
+	if path == "" {
+		return nil, errors.New("no scryfall bulk file is configured")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	bulkPrintingsCache.Lock()
+	defer bulkPrintingsCache.Unlock()
+	if bulkPrintingsCache.path == path && bulkPrintingsCache.modified.Equal(info.ModTime()) {
+		return bulkPrintingsCache.printings, nil
+	}
+	fmt.Println("Reading bulk file: " + path)
+	printings := make(map[string][]CardPrinting)
+	err = ForEachScryfallBulkCard(path, func(card gjson.Result) error {
+		if card.Get("digital").Bool() { // digital printings can not be bought
+			return nil
+		}
+		printing := ParseCardPrinting(card)
+		keys := []string{strings.ToLower(printing.Name)}
+		if face := strings.ToLower(card.Get("card_faces.0.name").String()); face != "" && face != keys[0] {
+			keys = append(keys, face)
+		}
+		for _, key := range keys {
+			printings[key] = append(printings[key], printing)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, cardPrintings := range printings {
+		slices.SortStableFunc(cardPrintings, func(a, b CardPrinting) int { return strings.Compare(b.ReleasedAt, a.ReleasedAt) })
+	}
+	bulkPrintingsCache.path = path
+	bulkPrintingsCache.modified = info.ModTime()
+	bulkPrintingsCache.printings = printings
+	return printings, nil
+}
+
+// CardmarketPriceProvider provides the prices of a Cardmarket price guide file in json or csv format.
+// Price guides identify cards by their Cardmarket product id, so the printings are taken from another provider
+// and their euro prices are replaced with the trend prices of the guide.
+type CardmarketPriceProvider struct {
+	Path      string
+	Printings PriceProvider // provider of the printings and their Cardmarket ids
+}
+
+// CardmarketPrice is an entry of a Cardmarket price guide
+type CardmarketPrice struct {
+	Trend     float64
+	FoilTrend float64
+}
+
+// priceGuideCache holds the last price guide that was read
+var priceGuideCache struct {
+	sync.Mutex
+	path     string
+	modified time.Time
+	prices   map[int64]CardmarketPrice // prices keyed by the Cardmarket product id
+}
+
+func (p CardmarketPriceProvider) Name() string {
+	return CardmarketPriceProviderName
+}
+
+func (p CardmarketPriceProvider) GetPrintings(names []string, options PricingOptions) (map[string][]CardPrinting, error) {
+	guide, err := loadPriceGuide(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	printings, err := p.Printings.GetPrintings(names, options)
+	if err != nil {
+		return nil, err
+	}
+	for name, cardPrintings := range printings {
+		cardPrintings = slices.Clone(cardPrintings) // the printings might belong to the cache of the bulk file
+		for i, printing := range cardPrintings {
+			price := guide[printing.CardmarketId] // printings that are not part of the guide fall back to their dollar prices
+			cardPrintings[i].Eur = price.Trend
+			cardPrintings[i].EurFoil = price.FoilTrend
+		}
+		printings[name] = cardPrintings
+	}
+	return printings, nil
+}
+
+// loadPriceGuide
+// Reads a Cardmarket price guide, the result is cached until the file changes
+// Params: the path of the price guide, files ending on .csv are read as csv, all others as json
+// Returns: the prices keyed by the Cardmarket product id and an error if the file could not be read
+func loadPriceGuide(path string) (map[int64]CardmarketPrice, error) {
+	if path == "" {
+		return nil, errors.New("no Cardmarket price guide is configured")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	priceGuideCache.Lock()
+	defer priceGuideCache.Unlock()
+	if priceGuideCache.path == path && priceGuideCache.modified.Equal(info.ModTime()) {
+		return priceGuideCache.prices, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var prices map[int64]CardmarketPrice
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		prices, err = ParsePriceGuideCsv(string(content))
+	} else {
+		prices, err = ParsePriceGuideJson(string(content))
+	}
+	if err != nil {
+		return nil, err
+	}
+	priceGuideCache.path = path
+	priceGuideCache.modified = info.ModTime()
+	priceGuideCache.prices = prices
+	return prices, nil
+}
+
+// ParsePriceGuideJson
+// Parses a Cardmarket price guide as it is published in json format
+// Params: the content of the price guide
+// Returns: the prices keyed by the Cardmarket product id and an error if the content is no price guide
+func ParsePriceGuideJson(guideJson string) (map[int64]CardmarketPrice, error) {
+	entries := gjson.Get(guideJson, "priceGuides")
+	if !entries.IsArray() {
+		return nil, errors.New("the price guide contains no \"priceGuides\" array")
+	}
+	prices := make(map[int64]CardmarketPrice)
+	for _, entry := range entries.Array() {
+		prices[entry.Get("idProduct").Int()] = CardmarketPrice{
+			Trend:     entry.Get("trend").Float(),
+			FoilTrend: entry.Get("trend-foil").Float(),
+		}
+	}
+	return prices, nil
+}
+
+// ParsePriceGuideCsv
+// Parses a Cardmarket price guide in csv format, the columns are identified by the header line
+// Params: the content of the price guide
+// Returns: the prices keyed by the Cardmarket product id and an error if the header lacks the product id or trend price
+func ParsePriceGuideCsv(guideCsv string) (map[int64]CardmarketPrice, error) {
+	reader := csv.NewReader(strings.NewReader(guideCsv))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("the price guide is empty")
+	}
+	columns := make(map[string]int)
+	for i, column := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	findColumn := func(names ...string) int {
+		for _, name := range names {
+			if i, ok := columns[name]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	idColumn := findColumn("idproduct", "id product", "product id")
+	trendColumn := findColumn("trend price", "trend", "trendprice")
+	foilColumn := findColumn("foil trend", "trend-foil", "trend foil", "foil trend price")
+	if idColumn < 0 || trendColumn < 0 {
+		return nil, errors.New("the price guide needs an \"idProduct\" and a \"Trend Price\" column")
+	}
+	field := func(record []string, column int) float64 {
+		if column < 0 || column >= len(record) {
+			return 0.0
+		}
+		value, _ := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(record[column]), ",", "."), 64)
+		return value
+	}
+	prices := make(map[int64]CardmarketPrice)
+	for _, record := range records[1:] {
+		if idColumn >= len(record) {
+			continue
+		}
+		id, err := strconv.ParseInt(strings.TrimSpace(record[idColumn]), 10, 64)
+		if err != nil {
+			continue
+		}
+		prices[id] = CardmarketPrice{Trend: field(record, trendColumn), FoilTrend: field(record, foilColumn)}
+	}
+	return prices, nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"github.com/tidwall/gjson"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PriceStrategy
+// Determines which printing of a card is used when the price of a deck is calculated
+type PriceStrategy string
+
+const (
+	PriceStrategyNewest   PriceStrategy = "Newest printing"
+	PriceStrategyCheapest PriceStrategy = "Cheapest printing"
+	PriceStrategyPinned   PriceStrategy = "Pinned printing"
+	PriceStrategyMedian   PriceStrategy = "Median of printings"
+)
+
+// PriceStrategies contains all strategies in the order they are offered inside the UI
+var PriceStrategies = []PriceStrategy{PriceStrategyNewest, PriceStrategyCheapest, PriceStrategyPinned, PriceStrategyMedian}
+
+const pinnedPrintingsPreferenceKey = "pinnedPrintings"
+
+// PinnedPrinting identifies a specific printing of a card a user wants to be used for pricing
+type PinnedPrinting struct {
+	Set             string `json:"set"`
+	CollectorNumber string `json:"collector_number"`
+}
+
+// PricingOptions bundles all settings that influence how the price of a deck is calculated
+type PricingOptions struct {
+	Strategy PriceStrategy
+	Pins     map[string]PinnedPrinting // pinned printings keyed by card name
+	UsdToEur float64                   // exchange rate for cards without a euro price, 0 if unknown
+	Provider PriceProvider
+}
+
+// PriceQuality describes how reliable the price of a single card is
+type PriceQuality int
+
+const (
+	PriceExact     PriceQuality = iota // the non-foil euro price
+	PriceEstimated                     // converted from dollars or taken from a foil price
+	PriceMissing                       // no price could be found at all
+)
+
+// DeckPrice is the result of a price check together with the strategy that produced it
+type DeckPrice struct {
+	Total       float64
+	Strategy    PriceStrategy
+	Source      string // name of the price provider
+	Estimated   int    // number of cards whose price was estimated
+	Missing     int    // number of cards without any price
+	MissingOnly bool   // true if only the cards missing from the collection were priced
+}
+
+// String
+// Formats the price for the price label in the UI
+// Returns: the total in euro followed by the strategy and source that were used and the number of estimated and missing prices
+func (p DeckPrice) String() string {
+	result := strconv.FormatFloat(p.Total, 'f', 2, 64) + "€ (" + strings.ToLower(string(p.Strategy)) + " via " + p.Source
+	if p.MissingOnly {
+		result += ", missing cards only"
+	}
+	result += ")"
+	if p.Estimated > 0 || p.Missing > 0 {
+		result += fmt.Sprintf(" - %d estimated, %d missing", p.Estimated, p.Missing)
+	}
+	return result
+}
+
+// add
+// Adds the price of one or more copies of a card to the deck price
+// Params: the price of a single copy, its quality and the amount of copies
+func (p *DeckPrice) add(price float64, quality PriceQuality, count int) {
+	p.Total += price * float64(count)
+	switch quality {
+	case PriceEstimated:
+		p.Estimated += count
+	case PriceMissing:
+		p.Missing += count
+	}
+}
+
+// CardPrinting holds the pricing relevant data of a single printing of a card
+// All prices are 0 if the price provider has no price for the printing
+type CardPrinting struct {
+	Name            string
+	Set             string
+	CollectorNumber string
+	ReleasedAt      string // release date formatted as YYYY-MM-DD
+	CardmarketId    int64
+	Eur             float64
+	Usd             float64
+	EurFoil         float64
+	UsdFoil         float64
+}
+
+// GetPrice
+// Determines the price of the printing in euro, falling back to the dollar price, then to the foil prices
+// Params: the exchange rate from dollar to euro, 0 if dollar prices can not be converted
+// Returns: the price in euro and how it was determined
+func (c CardPrinting) GetPrice(usdToEur float64) (float64, PriceQuality) {
+	switch {
+	case c.Eur > 0:
+		return c.Eur, PriceExact
+	case c.Usd > 0 && usdToEur > 0:
+		return c.Usd * usdToEur, PriceEstimated
+	case c.EurFoil > 0:
+		return c.EurFoil, PriceEstimated
+	case c.UsdFoil > 0 && usdToEur > 0:
+		return c.UsdFoil * usdToEur, PriceEstimated
+	default:
+		return 0.0, PriceMissing
+	}
+}
+
+// ParseCardPrinting
+// Extracts the pricing relevant fields of a scryfall card object
+// Params: the card object as a gjson.Result
+// Returns: the CardPrinting of the card object
+func ParseCardPrinting(card gjson.Result) CardPrinting {
+	return CardPrinting{
+		Name:            card.Get("name").String(),
+		Set:             card.Get("set").String(),
+		CollectorNumber: card.Get("collector_number").String(),
+		ReleasedAt:      card.Get("released_at").String(),
+		CardmarketId:    card.Get("cardmarket_id").Int(),
+		Eur:             card.Get("prices.eur").Float(),
+		Usd:             card.Get("prices.usd").Float(),
+		EurFoil:         card.Get("prices.eur_foil").Float(),
+		UsdFoil:         card.Get("prices.usd_foil").Float(),
+	}
+}
+
+// SelectPrintingPrice
+// Picks the price of a card from a list of its printings according to the given strategy.
+// Exact prices are preferred, estimated prices are only considered if no printing has an exact price.
+// Params: all printings that should be considered, the strategy and the exchange rate from dollar to euro
+// Returns: the price of the card and its quality
+func SelectPrintingPrice(printings []CardPrinting, strategy PriceStrategy, usdToEur float64) (float64, PriceQuality) {
+	prices := make(map[PriceQuality][]float64)
+	for _, printing := range printings {
+		price, quality := printing.GetPrice(usdToEur)
+		prices[quality] = append(prices[quality], price)
+	}
+	for _, quality := range []PriceQuality{PriceExact, PriceEstimated} {
+		candidates := prices[quality]
+		if len(candidates) == 0 {
+			continue
+		}
+		switch strategy {
+		case PriceStrategyCheapest:
+			return slices.Min(candidates), quality
+		case PriceStrategyMedian:
+			slices.Sort(candidates)
+			middle := len(candidates) / 2
+			if len(candidates)%2 == 0 {
+				return (candidates[middle-1] + candidates[middle]) / 2, quality
+			}
+			return candidates[middle], quality
+		default: // newest and pinned strategies only ever receive a single printing
+			return candidates[0], quality
+		}
+	}
+	return 0.0, PriceMissing
+}
+
+// ParseDeckListLine
+// Splits a decklist line formatted as "<amount> <Cardname>" into its parts
+// Params: the line as a string
+// Returns: the amount, the card name and false if the line could not be parsed
+func ParseDeckListLine(line string) (int, string, bool) {
+	amount, name, found := strings.Cut(strings.TrimSpace(line), " ")
+	if !found {
+		return 0, "", false
+	}
+	count, err := strconv.Atoi(amount)
+	if err != nil {
+		return 0, "", false
+	}
+	return count, strings.TrimSpace(name), true
+}
+
+// GetScryfallList
+// Retrieves all objects of a paginated scryfall list, following "next_page" until the list is exhausted
+// Params: the URI of the first page
+// Returns: all objects of the list and an error if any page could not be retrieved
+func GetScryfallList(uri string) ([]gjson.Result, error) {
+	result := make([]gjson.Result, 0)
+	for uri != "" {
+		page, err := GetScryfallCommanderData(uri)
+		if err != nil {
+			return nil, err
+		}
+		if gjson.Get(page, "object").String() == "error" {
+			return nil, fmt.Errorf("scryfall: %s", gjson.Get(page, "details").String())
+		}
+		result = append(result, gjson.Get(page, "data").Array()...)
+		uri = ""
+		if gjson.Get(page, "has_more").Bool() {
+			uri = gjson.Get(page, "next_page").String()
+		}
+	}
+	return result, nil
+}
+
+// GetDeckPricingData
+// Retrieves the printings of every card of a decklist from the price provider of the options and sums up their prices
+// params: a decklist as an [] string with every entry being formatted as "1 <CardName>" and the pricing options
+// returns the sum of prices of all cards in the decklist in euro, priced with the strategy and provider of the options
+func GetDeckPricingData(deck []string, options PricingOptions) DeckPrice {
+	counts := make(map[string]int)
+	names := make([]string, 0, len(deck))
+	for _, line := range deck {
+		count, name, ok := ParseDeckListLine(line)
+		if ok {
+			if _, known := counts[name]; !known {
+				names = append(names, name)
+			}
+			counts[name] += count
+		}
+	}
+	price := DeckPrice{Strategy: options.Strategy, Source: options.Provider.Name()}
+	printings, err := options.Provider.GetPrintings(names, options)
+	if err != nil {
+		fmt.Println("ERROR: " + err.Error())
+	}
+	for _, name := range names { // cards the provider does not know count as missing
+		cardPrice, quality := SelectPrintingPrice(printings[name], options.Strategy, options.UsdToEur)
+		price.add(cardPrice, quality, counts[name])
+	}
+	fmt.Println("Price:" + price.String())
+	return price
+}
+
+// LoadPinnedPrintings
+// Reads the pinned printings of the user from the app preferences
+// Params: the preferences of the app
+// Returns: the pinned printings keyed by card name
+func LoadPinnedPrintings(preferences fyne.Preferences) map[string]PinnedPrinting {
+	pins := make(map[string]PinnedPrinting)
+	stored := preferences.String(pinnedPrintingsPreferenceKey)
+	if stored != "" {
+		if err := json.Unmarshal([]byte(stored), &pins); err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		}
+	}
+	return pins
+}
+
+// SavePinnedPrintings
+// Stores the pinned printings of the user inside the app preferences
+// Params: the preferences of the app and the pinned printings keyed by card name
+func SavePinnedPrintings(preferences fyne.Preferences, pins map[string]PinnedPrinting) {
+	stored, err := json.Marshal(pins)
+	if err == nil {
+		preferences.SetString(pinnedPrintingsPreferenceKey, string(stored))
+	}
+}
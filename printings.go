@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/tidwall/gjson"
+	"strings"
+)
+
+// PrintingThumbnailLoads is the number of art crops the printings gallery loads at once
+var PrintingThumbnailLoads = 4
+
+// Printing is a printing of a card as it is listed in the printings gallery
+type Printing struct {
+	Card    gjson.Result // the scryfall card object of the printing
+	Title   string       // set name, set code and collector number
+	Artist  string
+	Frame   string
+	Price   string
+	ArtUri  string
+	Pinned  bool // the printing is the one the user pinned for the card
+	Current bool // the printing is the one that is displayed
+}
+
+// GetPrintings
+// Lists every printing of a card, newest first
+// Params: the scryfall card object as json, the pinned printings and the exchange rate from dollar to euro
+// Returns: the printings and an error if scryfall could not be reached
+func GetPrintings(card string, pins map[string]PinnedPrinting, usdToEur float64) ([]Printing, error) {
+	parsed := gjson.Parse(card)
+	uri := parsed.Get("prints_search_uri").String()
+	if uri == "" {
+		return nil, errors.New("scryfall lists no printings for " + parsed.Get("name").String())
+	}
+	prints, err := GetScryfallList(uri)
+	if err != nil {
+		return nil, err
+	}
+	pin, pinned := pins[parsed.Get("name").String()]
+	printings := make([]Printing, 0, len(prints))
+	for _, p := range prints {
+		printing := Printing{
+			Card:    p,
+			Title:   fmt.Sprintf("%s (%s) #%s", p.Get("set_name").String(), strings.ToUpper(p.Get("set").String()), p.Get("collector_number").String()),
+			Artist:  p.Get("artist").String(),
+			Frame:   describeFrame(p),
+			Price:   "no price",
+			ArtUri:  p.Get("image_uris.art_crop").String(),
+			Pinned:  pinned && p.Get("set").String() == pin.Set && p.Get("collector_number").String() == pin.CollectorNumber,
+			Current: p.Get("id").String() == parsed.Get("id").String(),
+		}
+		if printing.ArtUri == "" {
+			printing.ArtUri = p.Get("card_faces.0.image_uris.art_crop").String()
+		}
+		switch price, quality := ParseCardPrinting(p).GetPrice(usdToEur); quality {
+		case PriceExact:
+			printing.Price = fmt.Sprintf("%.2f€", price)
+		case PriceEstimated:
+			printing.Price = fmt.Sprintf("~%.2f€", price)
+		}
+		printings = append(printings, printing)
+	}
+	return printings, nil
+}
+
+// describeFrame names the frame of a printing, e.g. "2015 frame, borderless, showcase"
+func describeFrame(card gjson.Result) string {
+	parts := []string{card.Get("frame").String() + " frame"}
+	if border := card.Get("border_color").String(); border == "borderless" {
+		parts = append(parts, border)
+	}
+	if card.Get("full_art").Bool() {
+		parts = append(parts, "full art")
+	}
+	for _, effect := range card.Get("frame_effects").Array() {
+		parts = append(parts, effect.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ShowCommanderPrintings
+// Opens the printings gallery for the displayed commander, the chosen printing is displayed instead of the current one
+// Params: the app, the session state and a callback receiving the image of the chosen printing
+func ShowCommanderPrintings(a fyne.App, state *SessionState, chosen func(image fyne.Resource)) {
+	// the main window keeps working, the chosen printing only replaces the commander the gallery was opened for
+	index, commander := state.commanderCount-state.backSteps, GetCurrentCommander(state)
+	ShowPrintingsGallery(a, state, GetCurrentCard(state), func(printing gjson.Result) {
+		go func() {
+			_, imageUri := ParseScryfallData(printing.Raw)
+			image := GetImageResource(imageUri)
+			RunOnUi(func() {
+				if SetCommanderPrinting(state, index, commander, printing, image) {
+					chosen(image)
+				}
+			})
+		}()
+	})
+}
+
+// ShowPrintingsGallery
+// Opens a window listing every printing of a card with its art, set, artist, frame and price.
+// Choosing a printing pins it, so it is displayed, exported and priced with the pinned printing strategy.
+// Params: the app, the session state, the scryfall card object of the card as json and a callback receiving the chosen printing once it is pinned
+func ShowPrintingsGallery(a fyne.App, state *SessionState, card string, chosen func(printing gjson.Result)) {
+	w := a.NewWindow("Printings")
+	w.Resize(fyne.NewSize(820, 640))
+	if card == "" {
+		dialog.ShowError(errors.New("no card is selected"), w)
+		w.Show()
+		return
+	}
+	pins, usdToEur := state.pinnedPrintings, GetUsdToEurRate(state.preferences)
+	w.SetTitle("Printings of " + gjson.Get(card, "name").String())
+	w.SetContent(container.NewCenter(widget.NewLabel("Loading printings...")))
+	w.Show()
+	go func() {
+		printings, err := GetPrintings(card, pins, usdToEur)
+		if err != nil {
+			RunOnUi(func() { w.SetContent(container.NewCenter(widget.NewLabel("ERROR: " + err.Error()))) })
+			return
+		}
+		RunOnUi(func() { w.SetContent(newPrintingsView(state, w, printings, chosen)) })
+	}()
+}
+
+// newPrintingsView
+// Creates the gallery of the printings, the art crops are loaded in the background
+// Params: the session state, the gallery window, the printings and the callback of ShowPrintingsGallery
+// Returns: the scrollable gallery
+func newPrintingsView(state *SessionState, w fyne.Window, printings []Printing, chosen func(printing gjson.Result)) fyne.CanvasObject {
+	gallery := container.NewGridWrap(fyne.NewSize(250, 330))
+	loads := make(chan struct{}, PrintingThumbnailLoads)
+	for _, printing := range printings {
+		art := canvas.NewImageFromResource(nil)
+		art.FillMode = canvas.ImageFillContain
+		art.SetMinSize(fyne.NewSize(240, 176))
+		if printing.ArtUri != "" {
+			go func() {
+				loads <- struct{}{}
+				image := GetImageResource(printing.ArtUri)
+				<-loads
+				RunOnUi(func() {
+					art.Resource = image
+					art.Refresh()
+				})
+			}()
+		}
+		use := widget.NewButton("Use this printing", OnUi(func() {
+			PinPrinting(state, printing.Card)
+			w.Close()
+			chosen(printing.Card)
+		}))
+		if printing.Pinned {
+			use.SetText("Pinned")
+			use.Disable()
+		}
+		title := widget.NewLabelWithStyle(printing.Title, fyne.TextAlignLeading, fyne.TextStyle{Bold: printing.Current})
+		title.Truncation = fyne.TextTruncateEllipsis
+		artist := widget.NewLabel("Illustrated by " + printing.Artist)
+		artist.Truncation = fyne.TextTruncateEllipsis
+		frame := widget.NewLabel(printing.Frame)
+		frame.Truncation = fyne.TextTruncateEllipsis
+		gallery.Add(container.NewVBox(art, title, artist, frame, container.NewBorder(nil, nil, nil, use, widget.NewLabel(printing.Price))))
+	}
+	return container.NewVScroll(gallery)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/tidwall/gjson"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RulingsCacheDuration is how long rulings are read from the disk cache before they are retrieved again
+var RulingsCacheDuration = 30 * 24 * time.Hour
+
+// Ruling is a ruling or note scryfall knows for a card
+type Ruling struct {
+	Source    string // "wotc" for official rulings, "scryfall" for notes of scryfall
+	Published string // the date the ruling was published as yyyy-mm-dd
+	Comment   string
+}
+
+// CardRulings are the rulings of a single card
+type CardRulings struct {
+	Name    string
+	Rulings []Ruling
+}
+
+// GetScryfallRulings
+// Retrieves the rulings of a card from scryfall, the response is kept in the persistent cache
+// Params: the scryfall id of the card
+// Returns: the rulings, oldest first, and an error if they could not be retrieved
+func GetScryfallRulings(id string) ([]Ruling, error) {
+	path := ""
+	if directory := CacheDirectory("rulings"); directory != "" {
+		path = filepath.Join(directory, id+".json")
+	}
+	response := ""
+	if info, err := os.Stat(path); path != "" && err == nil && time.Since(info.ModTime()) < RulingsCacheDuration {
+		if content, err := os.ReadFile(path); err == nil {
+			response = string(content)
+		}
+	}
+	if response == "" {
+		var err error
+		response, err = GetScryfallCommanderData("https://api.scryfall.com/cards/" + id + "/rulings")
+		if err != nil {
+			return nil, err
+		}
+		if gjson.Get(response, "object").String() != "list" {
+			return nil, errors.New("scryfall returned no rulings: " + gjson.Get(response, "details").String())
+		}
+		if path != "" {
+			if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+				fmt.Println("ERROR: " + err.Error())
+			} else if err := writeFileAtomic(path, []byte(response)); err != nil {
+				fmt.Println("ERROR: " + err.Error())
+			}
+		}
+	}
+	rulings := make([]Ruling, 0)
+	for _, ruling := range gjson.Get(response, "data").Array() {
+		rulings = append(rulings, Ruling{
+			Source:    ruling.Get("source").String(),
+			Published: ruling.Get("published_at").String(),
+			Comment:   ruling.Get("comment").String(),
+		})
+	}
+	return rulings, nil
+}
+
+// GetCardRulings
+// Retrieves the rulings of a card and of the card it melds into, the faces of double faced cards share their rulings
+// Params: the scryfall card object as json
+// Returns: the rulings of each card and an error if they could not be retrieved
+func GetCardRulings(card string) ([]CardRulings, error) {
+	parsed := gjson.Parse(card)
+	if !parsed.Get("id").Exists() {
+		return nil, errors.New("the card has no scryfall id")
+	}
+	cards := []CardRulings{{Name: parsed.Get("name").String()}}
+	ids := []string{parsed.Get("id").String()}
+	for _, part := range parsed.Get("all_parts").Array() {
+		if part.Get("component").String() == "meld_result" && part.Get("id").String() != ids[0] {
+			cards = append(cards, CardRulings{Name: part.Get("name").String()})
+			ids = append(ids, part.Get("id").String())
+		}
+	}
+	for i, id := range ids {
+		rulings, err := GetScryfallRulings(id)
+		if err != nil {
+			return nil, err
+		}
+		cards[i].Rulings = rulings
+	}
+	return cards, nil
+}
+
+// NewRulingsPanel
+// Creates the rulings panel, the rulings are only retrieved once the user asks for them
+// Params: the session state
+// Returns: the panel
+func NewRulingsPanel(state *SessionState) *LazyPanel {
+	return NewLazyPanel(state, "Show rulings", "Loading rulings...", func(source deckSource) (fyne.CanvasObject, error) {
+		cards, err := GetCardRulings(source.card)
+		if err != nil {
+			return nil, err
+		}
+		return container.NewVScroll(NewRulingsView(cards)), nil
+	})
+}
+
+// NewRulingsView
+// Lists the rulings of each card with their date and source
+// Params: the rulings of each card
+// Returns: the view of the rulings
+func NewRulingsView(cards []CardRulings) fyne.CanvasObject {
+	view := container.NewVBox()
+	for _, card := range cards {
+		if len(cards) > 1 {
+			view.Add(widget.NewLabelWithStyle(card.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		}
+		if len(card.Rulings) == 0 {
+			view.Add(widget.NewLabel("There are no rulings for " + card.Name))
+		}
+		for _, ruling := range card.Rulings {
+			source := "Wizards of the Coast"
+			if ruling.Source == "scryfall" {
+				source = "Scryfall"
+			}
+			view.Add(widget.NewLabelWithStyle(ruling.Published+" ("+source+")", fyne.TextAlignLeading, fyne.TextStyle{Italic: true}))
+			comment := widget.NewLabel(ruling.Comment)
+			comment.Wrapping = fyne.TextWrapWord
+			view.Add(comment)
+		}
+	}
+	return view
+}
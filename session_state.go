@@ -4,26 +4,45 @@ import (
 	"errors"
 	"fmt"
 	"fyne.io/fyne/v2"
+	"github.com/tidwall/gjson"
+	"maps"
+	"net/url"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 )
 
 type SessionState struct {
-	commanderCount          int
-	backSteps               int
-	currentCardFace         int
-	prevCommanderNames      []string
-	prevCommanderImages     []fyne.Resource
-	prevCommanderDecklists  []string
-	prevCommanderDeckPrices []float64
+	commanderCount              int
+	backSteps                   int
+	prevCommanderNames          []string
+	prevCommanderImages         []fyne.Resource
+	prevCommanderFaces          []int                // index into CardFaces of the card of each commander, the face that is displayed
+	prevCommanderCards          []string             // scryfall card object of each commander as json
+	prevCommanderThemes         []string             // slug of the EDHRec theme selected for each commander, "" for all decks
+	prevCommanderDecks          []*ImportedDeck      // the imported deck of each commander, nil if the deck comes from the deck provider
+	prevCommanderCoverage       []*Coverage          // how much of the deck of each commander the collection covers, nil if it was not drawn for its coverage
+	coverageQueue               []CoverageCandidate  // ranked commanders covered by the collection that were not offered yet
+	coverageQueueKey            string               // the colors, query, coverage, budget and collection the queue was drawn for
+	deckCache                   map[string]string    // decklists keyed by the cache key of their provider
+	priceCache                  map[string]DeckPrice // deck prices keyed by the cache key of the deck, the price strategy and the price provider
+	deckVariant                 DeckVariant
+	themeCache                  map[string][]EdhrecTheme // EDHRec themes keyed by commander name
+	resolvedDeckCache           map[string]ResolvedDeck  // decks with their scryfall card objects keyed by decklist
+	prevCommanderDeckPrefetches []*DeckPrefetch          // decklist and price of each commander, loaded in the background once it is displayed
+	prefetch                    *commanderPrefetch       // the next random commander, drawn in the background
+	mutex                       sync.Mutex               // guards caches that are filled in the background
+	priceStrategy               PriceStrategy
+	pinnedPrintings             map[string]PinnedPrinting
+	preferences                 fyne.Preferences
 }
 
 func GetPreviousCommanderData(state *SessionState) fyne.Resource {
 	if state.commanderCount > 0 && state.backSteps < state.commanderCount {
 		state.backSteps += 1
 		if len(state.prevCommanderImages) > 0 {
-			resource := state.prevCommanderImages[state.commanderCount-state.backSteps]
-			return resource
+			return GetCurrentCardImage(state)
 		} else {
 			return nil
 		}
@@ -32,76 +51,419 @@ func GetPreviousCommanderData(state *SessionState) fyne.Resource {
 	}
 }
 
-func AddNewCommanderDataToCache(state *SessionState, name string, image fyne.Resource) {
+func AddNewCommanderDataToCache(state *SessionState, name string, image fyne.Resource, card string) {
 	state.commanderCount += 1
 	state.prevCommanderNames = append(state.prevCommanderNames, name)
 	state.prevCommanderImages = append(state.prevCommanderImages, image)
-	state.prevCommanderDecklists = append(state.prevCommanderDecklists, "")    // add placeholder values for now
-	state.prevCommanderDeckPrices = append(state.prevCommanderDeckPrices, 0.0) // add placeholder values for now
+	state.prevCommanderCards = append(state.prevCommanderCards, card)
+	state.prevCommanderFaces = append(state.prevCommanderFaces, 0)
+	state.prevCommanderThemes = append(state.prevCommanderThemes, "")
+	state.prevCommanderDecks = append(state.prevCommanderDecks, nil)
+	state.prevCommanderCoverage = append(state.prevCommanderCoverage, nil)
+	state.mutex.Lock()
+	state.prevCommanderDeckPrefetches = append(state.prevCommanderDeckPrefetches, nil)
+	state.mutex.Unlock()
 }
 
-func GetNextCommanderData(state *SessionState, selected []string, queryEntry string) fyne.Resource {
-	if state.backSteps == 0 {
-		name, imageUri := GetCommanderFromScryfall(selected, queryEntry) // get any first commander (nothing selected)
-		fmt.Println(name + " : " + imageUri)
-		image := GetImageResource(imageUri)
-		AddNewCommanderDataToCache(state, name, image)
-		return image
-	} else {
+// LoadImportedCommander
+// Looks up the (first) commander of an imported deck on scryfall and loads its image, the pinned printing of the commander is preferred
+// Params: the imported deck and the pinned printings
+// Returns: the scryfall card object of the commander, its image and an error if scryfall does not know the commander
+func LoadImportedCommander(deck ImportedDeck, pins map[string]PinnedPrinting) (string, fyne.Resource, error) {
+	card, err := GetScryfallCommanderData("https://api.scryfall.com/cards/named?exact=" + url.QueryEscape(deck.Commanders[0]))
+	if err != nil {
+		return "", nil, err
+	}
+	if gjson.Get(card, "object").String() != "card" {
+		return "", nil, errors.New("scryfall does not know the commander " + deck.Commanders[0])
+	}
+	if pinned, _, ok := pinnedCommanderCard(pins, card); ok {
+		card = pinned
+	}
+	_, imageUri := ParseScryfallData(card)
+	return card, GetImageResource(imageUri), nil
+}
+
+// AddImportedDeck
+// Appends the commander of an imported deck with the deck to the end of the history and displays it
+// Params: the session state, the imported deck and the card object and image of its commander from LoadImportedCommander
+func AddImportedDeck(state *SessionState, deck ImportedDeck, card string, image fyne.Resource) {
+	name, _ := ParseScryfallData(card)
+	AddNewCommanderDataToCache(state, name, image, card)
+	state.prevCommanderDecks[state.commanderCount] = &deck
+	state.backSteps = 0
+}
+
+// GetNextCommanderData
+// Moves forward in the history or draws a new commander in the background if we are at its end, the window keeps working while it is drawn.
+// With a budget set, commanders are drawn until the price of their decklist fits into it.
+// With a minimum coverage set, only commanders whose deck is covered by the collection are offered, the best covered first.
+// Params: the session state, the selected colors, the search query, the budget in euro (0 for no budget), the minimum coverage in percent (0 to ignore the collection),
+// a callback reporting the progress of the search and a callback receiving the image of the commander once it is added to the history
+// or an error if no commander within the budget or coverage was found. Both callbacks are called on the UI thread.
+func GetNextCommanderData(state *SessionState, selected []string, queryEntry string, budget float64, minCoverage float64, progress func(string), done func(fyne.Resource, error)) {
+	if state.backSteps > 0 {
 		state.backSteps -= 1
-		resource := state.prevCommanderImages[state.commanderCount-state.backSteps]
-		return resource
+		done(GetCurrentCardImage(state), nil)
+		return
 	}
+	search := newCommanderSearch(state, selected, queryEntry, budget, minCoverage)
+	if minCoverage > 0 {
+		drawCoveredCommander(state, search, progress, done)
+		return
+	}
+	go func() {
+		commander, err := search.drawWithinBudget(state, progress)
+		RunOnUi(func() {
+			if err != nil {
+				done(nil, err)
+				return
+			}
+			done(addDrawnCommander(state, commander), nil)
+		})
+	}()
 }
-func GetCurrentDeckList(state *SessionState) string {
-	if len(state.prevCommanderImages) > 0 {
-		deckList := state.prevCommanderDecklists[state.commanderCount-state.backSteps]
-		if deckList != "" {
-			return deckList
-		} else {
-			deckList, err := GetEDHRecAvgDecklist(state.prevCommanderNames[state.commanderCount-state.backSteps])
-			if err == nil {
-				state.prevCommanderDecklists[state.commanderCount-state.backSteps] = deckList
-				return deckList
+
+// commanderSearch is what a new commander is drawn for, it is captured on the UI thread so the draws can run in the background
+type commanderSearch struct {
+	selected    []string
+	query       string
+	budget      float64  // 0 for no budget
+	minCoverage float64  // 0 to ignore the collection
+	drawn       []string // the commanders of the history, they are not drawn again
+	pins        map[string]PinnedPrinting
+	source      deckSource // the deck variant and pricing options the drawn commanders are priced with
+}
+
+// newCommanderSearch captures the history and the options a new commander is drawn with
+func newCommanderSearch(state *SessionState, selected []string, query string, budget float64, minCoverage float64) commanderSearch {
+	source := newDeckSource(state, "", "", nil)
+	source.missingOnly = false // the budget is for the whole deck, "Only missing cards" only changes the displayed price
+	return commanderSearch{
+		selected:    slices.Clone(selected),
+		query:       query,
+		budget:      budget,
+		minCoverage: minCoverage,
+		drawn:       slices.Clone(state.prevCommanderNames),
+		pins:        state.pinnedPrintings,
+		source:      source,
+	}
+}
+
+// deckSource returns the source of the deck of a drawn commander
+func (s commanderSearch) deckSource(name string) deckSource {
+	source := s.source
+	source.name, source.commander = name, name
+	return source
+}
+
+// drawnCommander is a commander drawn in the background, it is added to the history on the UI thread
+type drawnCommander struct {
+	name     string
+	card     string
+	image    fyne.Resource
+	coverage *Coverage // nil if the commander was not drawn for its coverage
+}
+
+// drawWithinBudget
+// Draws commanders until the price of the deck of one fits into the budget, without a budget the first commander is taken
+// Params: the session state and a callback reporting the progress on the UI thread
+// Returns: the commander and an error if no commander within the budget was found
+func (s commanderSearch) drawWithinBudget(state *SessionState, progress func(string)) (drawnCommander, error) {
+	for draw := range MaxBudgetDraws {
+		name, imageUri, card, image, prefetched := "", "", "", fyne.Resource(nil), false
+		if draw == 0 {
+			name, imageUri, card, image, prefetched = takePrefetchedCommander(state, s.selected, s.query, s.drawn)
+		}
+		if !prefetched {
+			name, imageUri, card = GetCommanderFromScryfall(s.selected, s.query) // get any first commander (nothing selected)
+		}
+		fmt.Println(name + " : " + imageUri)
+		if s.budget > 0 {
+			if name == "" { // the query itself failed, drawing again won't help
+				return drawnCommander{}, errors.New("no commander found for the query")
+			}
+			RunOnUi(func() { progress(fmt.Sprintf("Pricing %s (%d of %d)...", name, draw+1, MaxBudgetDraws)) })
+			if price := s.deckSource(name).price(state); price.Total == 0.0 || price.Total > s.budget {
+				continue
 			}
 		}
+		if pinned, pinnedUri, ok := pinnedCommanderCard(s.pins, card); ok { // the user prefers another printing of the commander
+			card, imageUri, prefetched = pinned, pinnedUri, false
+		}
+		if !prefetched {
+			image = GetImageResource(imageUri)
+		}
+		return drawnCommander{name: name, card: card, image: image}, nil
+	}
+	return drawnCommander{}, fmt.Errorf("no commander with a deck below %.2f€ found in %d draws", s.budget, MaxBudgetDraws)
+}
+
+// addDrawnCommander appends a drawn commander to the end of the history and returns its image, it has to be called on the UI thread
+func addDrawnCommander(state *SessionState, commander drawnCommander) fyne.Resource {
+	AddNewCommanderDataToCache(state, commander.name, commander.image, commander.card)
+	state.prevCommanderCoverage[state.commanderCount] = commander.coverage
+	state.backSteps = 0 // the user may have gone back while the commander was drawn
+	return commander.image
+}
+
+// GetDeckList
+// Returns the decklist of the selected variant for a commander and theme from the selected deck provider, retrieving it only if it is not cached yet
+// Params: the session state, the formatted name of the commander and the slug of the theme ("" for all decks)
+// Returns: the decklist, an empty string if it could not be retrieved
+func GetDeckList(state *SessionState, commander string, theme string) string {
+	return newDeckSource(state, commander, theme, nil).deckList(state)
+}
+
+// GetDeckPrice
+// Returns the price of a commanders decklist for the selected variant and strategy, calculating it only if it is not cached yet
+// Params: the session state, the formatted name of the commander and the slug of the theme ("" for all decks)
+// Returns: the price of the deck, with a total of 0 if there is no decklist
+func GetDeckPrice(state *SessionState, commander string, theme string) DeckPrice {
+	return newDeckSource(state, commander, theme, nil).price(state)
+}
+
+func GetCurrentDeckList(state *SessionState) string {
+	source, ok := currentDeckSource(state)
+	if !ok {
+		return ""
+	}
+	return source.deckList(state)
+}
+
+// GetCurrentDeck
+// Builds the deck of the current commander with the commander in its own section
+// Params: the session state
+// Returns: the Deck and an error if no commander is displayed or it has no decklist
+func GetCurrentDeck(state *SessionState) (Deck, error) {
+	source, ok := currentDeckSource(state)
+	if !ok {
+		return Deck{}, errors.New("no commander is displayed")
+	}
+	return source.deck(state)
+}
+
+// SetCurrentImportedDeck
+// Replaces the deck of the current commander, e.g. with a saved variant
+// Params: the session state and the deck, nil to use the deck provider again
+func SetCurrentImportedDeck(state *SessionState, deck *ImportedDeck) {
+	if len(state.prevCommanderImages) > 0 {
+		state.prevCommanderDecks[state.commanderCount-state.backSteps] = deck
+	}
+}
+
+// GetCurrentImportedDeck
+// Returns the imported deck or saved variant of the current commander, nil if the deck comes from the deck provider
+func GetCurrentImportedDeck(state *SessionState) *ImportedDeck {
+	if len(state.prevCommanderImages) > 0 {
+		return state.prevCommanderDecks[state.commanderCount-state.backSteps]
+	}
+	return nil
+}
+
+// GetCurrentCoverage
+// Returns the coverage of the current commanders deck by the collection, nil if the commander was not drawn for its coverage
+func GetCurrentCoverage(state *SessionState) *Coverage {
+	if len(state.prevCommanderImages) > 0 {
+		return state.prevCommanderCoverage[state.commanderCount-state.backSteps]
+	}
+	return nil
+}
+
+// GetCurrentCommander
+// Returns the formatted name of the current commander, "" if there is none
+func GetCurrentCommander(state *SessionState) string {
+	if len(state.prevCommanderImages) > 0 {
+		return state.prevCommanderNames[state.commanderCount-state.backSteps]
 	}
 	return ""
 }
-func GetCurrentDeckPrice(state *SessionState) float64 {
+
+// GetCurrentCard returns the scryfall card object of the displayed commander as json, "" if no commander is displayed
+func GetCurrentCard(state *SessionState) string {
 	if len(state.prevCommanderImages) > 0 {
-		price := state.prevCommanderDeckPrices[state.commanderCount-state.backSteps]
-		if price != 0.0 {
-			return price
-		} else {
-			deckList := GetCurrentDeckList(state)
-			if deckList == "" {
-				return 0.0
-			} else {
-				price := GetScryfallPricingData(strings.Split(deckList, "\n"), 2)
-				state.prevCommanderDeckPrices[state.commanderCount-state.backSteps] = price
-				return price
-			}
+		return state.prevCommanderCards[state.commanderCount-state.backSteps]
+	}
+	return ""
+}
+
+// ResolvedDeck is a deck whose cards carry their scryfall card objects
+type ResolvedDeck struct {
+	Deck     Deck
+	NotFound []string // names scryfall could not match
+}
+
+// GetCurrentResolvedDeck
+// Returns the deck of the current commander with the scryfall card objects of its cards, resolving it only if it is not cached yet
+// Params: the session state
+// Returns: the ResolvedDeck and an error if there is no deck or scryfall could not be reached
+func GetCurrentResolvedDeck(state *SessionState) (ResolvedDeck, error) {
+	source, ok := currentDeckSource(state)
+	if !ok {
+		return ResolvedDeck{}, errors.New("no commander is displayed")
+	}
+	return source.resolvedDeck(state)
+}
+
+func GetCurrentDeckPrice(state *SessionState) DeckPrice {
+	source, ok := currentDeckSource(state)
+	if !ok {
+		return DeckPrice{Strategy: state.priceStrategy, Source: GetSelectedPriceProvider(state.preferences).Name()}
+	}
+	return source.price(state)
+}
+
+// GetThemes
+// Returns the EDHRec themes of a commander, retrieving them only if they are not cached yet
+// Params: the session state and the formatted name of the commander
+// Returns: the themes of the commander, nil if they could not be retrieved
+func GetThemes(state *SessionState, commander string) []EdhrecTheme {
+	if commander == "" {
+		return nil
+	}
+	state.mutex.Lock()
+	themes, ok := state.themeCache[commander]
+	state.mutex.Unlock()
+	if ok {
+		return themes
+	}
+	themes, err := GetEDHRecThemes(commander)
+	if err != nil {
+		fmt.Println("ERROR: " + err.Error())
+		return nil
+	}
+	state.mutex.Lock()
+	state.themeCache[commander] = themes
+	state.mutex.Unlock()
+	return themes
+}
+
+// SetCurrentTheme
+// Selects the EDHRec theme whose decks are used for the current commander
+// Params: the session state and the slug of the theme, "" for all decks
+func SetCurrentTheme(state *SessionState, theme string) {
+	if len(state.prevCommanderImages) > 0 {
+		state.prevCommanderThemes[state.commanderCount-state.backSteps] = theme
+	}
+}
+
+// GetCurrentTheme
+// Returns the slug of the EDHRec theme selected for the current commander, "" for all decks
+func GetCurrentTheme(state *SessionState) string {
+	if len(state.prevCommanderImages) > 0 {
+		return state.prevCommanderThemes[state.commanderCount-state.backSteps]
+	}
+	return ""
+}
+
+// GetPricingOptions
+// Collects the pricing options currently selected in the session
+// Params: the session state
+// Returns: the PricingOptions for price checks
+func GetPricingOptions(state *SessionState) PricingOptions {
+	return PricingOptions{
+		Strategy: state.priceStrategy,
+		Pins:     state.pinnedPrintings,
+		UsdToEur: GetUsdToEurRate(state.preferences),
+		Provider: GetSelectedPriceProvider(state.preferences),
+	}
+}
+
+// pinnedCommanderCard
+// Looks up the printing of a commander the user pinned
+// Params: the pinned printings and the scryfall card object of the commander as json
+// Returns: the card object and the image uri of the pinned printing and false if no other printing is pinned or it could not be retrieved
+func pinnedCommanderCard(pins map[string]PinnedPrinting, card string) (string, string, bool) {
+	pin, ok := pins[gjson.Get(card, "name").String()]
+	if !ok || (gjson.Get(card, "set").String() == pin.Set && gjson.Get(card, "collector_number").String() == pin.CollectorNumber) {
+		return "", "", false
+	}
+	pinned, err := GetScryfallCommanderData("https://api.scryfall.com/cards/" + url.PathEscape(pin.Set) + "/" + url.PathEscape(pin.CollectorNumber))
+	if err != nil || gjson.Get(pinned, "object").String() != "card" {
+		fmt.Println("ERROR: the pinned printing " + pin.Set + " " + pin.CollectorNumber + " could not be retrieved")
+		return "", "", false
+	}
+	_, imageUri := ParseScryfallData(pinned)
+	return pinned, imageUri, true
+}
+
+// PinPrinting
+// Pins a printing of a card, the pin is used to display the commander, by the export and by the pinned printing price strategy
+// Params: the session state and the scryfall card object of the printing
+func PinPrinting(state *SessionState, printing gjson.Result) {
+	pins := maps.Clone(state.pinnedPrintings) // prices that are checked in the background still read the old pins
+	pins[printing.Get("name").String()] = PinnedPrinting{Set: printing.Get("set").String(), CollectorNumber: printing.Get("collector_number").String()}
+	SavePinnedPrintings(state.preferences, pins)
+	state.mutex.Lock()
+	state.pinnedPrintings = pins
+	for key := range state.priceCache { // the prices of the pinned strategy include the pinned printing
+		if strings.Contains(key, "|"+string(PriceStrategyPinned)+"|") {
+			delete(state.priceCache, key)
 		}
 	}
-	return 0.0
+	state.resolvedDeckCache = make(map[string]ResolvedDeck)
+	for i := range state.prevCommanderDeckPrefetches {
+		state.prevCommanderDeckPrefetches[i] = nil
+	}
+	state.mutex.Unlock()
 }
 
-func GetOtherCardFaceForCurrentCard(state *SessionState) fyne.Resource {
-	currCommanderName := state.prevCommanderNames[state.commanderCount-state.backSteps]
-	if state.currentCardFace == 0 {
-		state.currentCardFace = 1
-	} else {
-		state.currentCardFace = 0
+// SetCommanderPrinting
+// Displays a commander with another printing, as long as it is still the displayed commander
+// Params: the session state, the index of the commander in the history, its formatted name, the scryfall card object of the printing and its image
+// Returns: false if another commander is displayed by now
+func SetCommanderPrinting(state *SessionState, index int, commander string, printing gjson.Result, image fyne.Resource) bool {
+	if len(state.prevCommanderImages) == 0 || state.commanderCount-state.backSteps != index || state.prevCommanderNames[index] != commander {
+		return false
 	}
+	state.prevCommanderCards[index] = printing.Raw
+	state.prevCommanderImages[index] = image
+	state.prevCommanderFaces[index] = 0
+	return true
+}
+
+// GetCurrentCardFace returns the displayed face of the card of the displayed commander
+func GetCurrentCardFace(state *SessionState) CardFace {
+	index := state.commanderCount - state.backSteps
+	faces := CardFaces(state.prevCommanderCards[index])
+	return faces[min(state.prevCommanderFaces[index], len(faces)-1)]
+}
+
+// GetCurrentCardImage returns the image of the displayed face of the displayed commander, the front face is never loaded twice
+func GetCurrentCardImage(state *SessionState) fyne.Resource {
+	index := state.commanderCount - state.backSteps
+	if state.prevCommanderFaces[index] == 0 {
+		return state.prevCommanderImages[index]
+	}
+	if image := GetCardFaceImage(GetCurrentCardFace(state)); image != nil {
+		return image
+	}
+	return state.prevCommanderImages[index]
+}
 
-	uri := GetAlternateCardFace(currCommanderName, state.currentCardFace)
-	println(uri)
-	resource := GetImageResource(uri)
-	if resource.Name() == resourcePlaceholderPng.Name() {
+// GetOtherCardFaceForCurrentCard
+// Flips the card of the displayed commander to its next face, the face is remembered for the commander
+// Params: the session state
+// Returns: the image of the next face, nil if the card can not be flipped or the image could not be loaded
+func GetOtherCardFaceForCurrentCard(state *SessionState) fyne.Resource {
+	if len(state.prevCommanderImages) == 0 {
+		return nil
+	}
+	index := state.commanderCount - state.backSteps
+	faces := CardFaces(state.prevCommanderCards[index])
+	if len(faces) < 2 {
 		return nil
 	}
-	return resource
+	face := (state.prevCommanderFaces[index] + 1) % len(faces)
+	image := state.prevCommanderImages[index]
+	if face != 0 {
+		image = GetCardFaceImage(faces[face])
+		if image == nil {
+			return nil
+		}
+	}
+	state.prevCommanderFaces[index] = face
+	return image
 }
 
 func PersistCompleteDataSets(state *SessionState) {
@@ -116,7 +478,7 @@ func PersistCompleteDataSets(state *SessionState) {
 			os.Create(cacheDir + string(os.PathSeparator) + "CommandTower" + string(os.PathListSeparator) + "commander_data.json")
 		}
 		for i := range state.commanderCount {
-			if state.prevCommanderImages[i] != nil && state.prevCommanderDecklists != nil {
+			if state.prevCommanderImages[i] != nil && state.deckCache[GetSelectedDeckProvider(state.preferences).CacheKey(state.prevCommanderNames[i], state.prevCommanderThemes[i], DeckVariantAverage)] != "" {
 				// TODO: ADD MARSHALLING
 			}
 		}
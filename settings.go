@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"strconv"
+	"strings"
+)
+
+// ShowSettingsDialog
+// Opens a form dialog that lets the user change the persistent settings of the app
+// Params: the window the dialog belongs to and the preferences of the app
+func ShowSettingsDialog(w fyne.Window, preferences fyne.Preferences) {
+	// exchange rate, left empty to use the fetched rate
+	rateEntry := widget.NewEntry()
+	rateEntry.PlaceHolder = "fetched automatically"
+	if rate := preferences.Float(usdToEurRatePreferenceKey); rate > 0 {
+		rateEntry.SetText(strconv.FormatFloat(rate, 'f', -1, 64))
+	}
+	rateEntry.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		_, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		return err
+	}
+
+	// deck source
+	providerSelect := widget.NewSelect(DeckProviderNames, nil)
+	providerSelect.SetSelected(preferences.StringWithFallback(deckProviderPreferenceKey, EdhrecDeckProviderName))
+	deckExportsDir := NewPathEntry(w, preferences.String(deckExportsDirPreferenceKey), true)
+	teamDecksDir := NewPathEntry(w, preferences.String(teamDecksDirPreferenceKey), true)
+
+	// price source
+	priceProviderSelect := widget.NewSelect(PriceProviderNames, nil)
+	priceProviderSelect.SetSelected(preferences.StringWithFallback(priceProviderPreferenceKey, ScryfallPriceProviderName))
+	bulkFile := NewPathEntry(w, preferences.String(scryfallBulkFilePreferenceKey), false)
+	priceGuideFile := NewPathEntry(w, preferences.String(priceGuideFilePreferenceKey), false)
+
+	// collection
+	collectionFile := NewPathEntry(w, preferences.String(collectionFilePreferenceKey), false)
+
+	// exports
+	exportDir := NewPathEntry(w, preferences.StringWithFallback(exportDirPreferenceKey, DefaultExportDirectory()), true)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("USD → EUR rate", rateEntry),
+		widget.NewFormItem("Deck source", providerSelect),
+		widget.NewFormItem("Deck exports folder", deckExportsDir.container),
+		widget.NewFormItem("Team deck folder", teamDecksDir.container),
+		widget.NewFormItem("Price source", priceProviderSelect),
+		widget.NewFormItem("Scryfall bulk file", bulkFile.container),
+		widget.NewFormItem("Cardmarket price guide", priceGuideFile.container),
+		widget.NewFormItem("Collection (csv)", collectionFile.container),
+		widget.NewFormItem("Export folder", exportDir.container),
+	}
+	dialog.ShowForm("Settings", "Save", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		rate, _ := strconv.ParseFloat(strings.TrimSpace(rateEntry.Text), 64) // an empty entry resets the rate to 0
+		preferences.SetFloat(usdToEurRatePreferenceKey, rate)
+		preferences.SetString(deckProviderPreferenceKey, providerSelect.Selected)
+		preferences.SetString(deckExportsDirPreferenceKey, strings.TrimSpace(deckExportsDir.entry.Text))
+		preferences.SetString(teamDecksDirPreferenceKey, strings.TrimSpace(teamDecksDir.entry.Text))
+		preferences.SetString(priceProviderPreferenceKey, priceProviderSelect.Selected)
+		preferences.SetString(scryfallBulkFilePreferenceKey, strings.TrimSpace(bulkFile.entry.Text))
+		preferences.SetString(priceGuideFilePreferenceKey, strings.TrimSpace(priceGuideFile.entry.Text))
+		preferences.SetString(collectionFilePreferenceKey, strings.TrimSpace(collectionFile.entry.Text))
+		preferences.SetString(exportDirPreferenceKey, strings.TrimSpace(exportDir.entry.Text))
+	}, w)
+}
+
+// PathEntry is an entry for a file or folder path with a button that opens a file or folder dialog
+type PathEntry struct {
+	entry     *widget.Entry
+	container *fyne.Container
+}
+
+// NewPathEntry
+// Creates an entry for a file or folder path with a button to browse for it
+// Params: the window the dialog belongs to, the initial path and true if the path is a folder
+// Returns: the PathEntry
+func NewPathEntry(w fyne.Window, path string, folder bool) *PathEntry {
+	entry := widget.NewEntry()
+	entry.SetText(path)
+	browse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		if folder {
+			dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+				if err == nil && uri != nil {
+					entry.SetText(uri.Path())
+				}
+			}, w)
+		} else {
+			dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+				if err == nil && reader != nil {
+					entry.SetText(reader.URI().Path())
+					reader.Close()
+				}
+			}, w)
+		}
+	})
+	return &PathEntry{entry: entry, container: container.NewBorder(nil, nil, nil, browse, entry)}
+}
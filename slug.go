@@ -0,0 +1,50 @@
+package main
+
+import (
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+// ligatureReplacer spells out letters that have no decomposition into a base letter and a diacritic
+var ligatureReplacer = strings.NewReplacer(
+	"æ", "ae",
+	"œ", "oe",
+	"ß", "ss",
+	"ø", "o",
+	"ð", "d",
+	"þ", "th",
+)
+
+// EdhrecSlug
+// Formats a card name the way EDHRec does for its URLs, e.g. "Atraxa, Praetors' Voice" -> "atraxa-praetors-voice".
+// Only the front face of multi-faced cards is used, diacritics are removed, whitespace and hyphens become single dashes
+// and all other characters that are not letters or digits are dropped.
+// Params: the name of the card as scryfall returns it
+// Returns: the slug of the card
+func EdhrecSlug(cardName string) string {
+	frontFace, _, _ := strings.Cut(cardName, "//")
+	name := ligatureReplacer.Replace(strings.ToLower(strings.TrimSpace(frontFace)))
+	transformer := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if stripped, _, err := transform.String(transformer, name); err == nil {
+		name = stripped
+	}
+
+	var slug strings.Builder
+	dash := false // a dash is only written once the next letter or digit follows
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			if dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			dash = false
+			slug.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_' || r == '/':
+			dash = true
+		}
+	}
+	return slug.String()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEdhrecSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		card string
+		slug string // the slug of the card on edhrec.com/cards
+	}{
+		{name: "commas and apostrophes", card: "Atraxa, Praetors' Voice", slug: "atraxa-praetors-voice"},
+		{name: "ligature", card: "Æther Vial", slug: "aether-vial"},
+		{name: "ligature inside a word", card: "Aethersnipe", slug: "aethersnipe"},
+		{name: "diacritics", card: "Jötun Grunt", slug: "jotun-grunt"},
+		{name: "quotation marks", card: "Kongming, \"Sleeping Dragon\"", slug: "kongming-sleeping-dragon"},
+		{name: "exclamation marks", card: "\"Ach! Hans, Run!\"", slug: "ach-hans-run"},
+		{name: "hyphen", card: "Lim-Dûl the Necromancer", slug: "lim-dul-the-necromancer"},
+		{name: "colon", card: "Circle of Protection: Red", slug: "circle-of-protection-red"},
+		{name: "digits", card: "Borrowing 100,000 Arrows", slug: "borrowing-100000-arrows"},
+		{name: "double faced", card: "Delver of Secrets // Insectile Aberration", slug: "delver-of-secrets"},
+		{name: "double faced commander", card: "Esika, God of the Tree // The Prismatic Bridge", slug: "esika-god-of-the-tree"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if slug := EdhrecSlug(test.card); slug != test.slug {
+				t.Errorf("EdhrecSlug(%q) = %q, want %q", test.card, slug, test.slug)
+			}
+		})
+	}
+}
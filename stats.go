@@ -0,0 +1,112 @@
+package main
+
+import (
+	"github.com/tidwall/gjson"
+	"regexp"
+	"strings"
+)
+
+// CardTypes contains the types cards are grouped by, in the order a card with several types is assigned to them
+var CardTypes = []string{"Creature", "Planeswalker", "Battle", "Land", "Instant", "Sorcery", "Artifact", "Enchantment"}
+
+// ManaColors contains the colors of mana symbols in WUBRG order followed by colorless
+var ManaColors = []string{"W", "U", "B", "R", "G", "C"}
+
+// MaxCurveManaValue is the last column of the mana curve, it also counts all cards with a higher mana value
+const MaxCurveManaValue = 7
+
+var manaSymbolRegex = regexp.MustCompile(`\{([^}]+)\}`)
+
+// DeckStats are the statistics of a resolved deck
+type DeckStats struct {
+	Curve            [MaxCurveManaValue + 1]int // number of nonland cards per mana value
+	Types            map[string]int             // number of cards per primary type
+	Lands            int
+	Nonlands         int
+	Pips             map[string]int // number of colored mana symbols in the mana costs of the cards
+	Sources          map[string]int // number of lands that can produce each color
+	AverageManaValue float64        // average mana value of the nonland cards
+	Unresolved       int            // number of cards without scryfall data, they are not part of the statistics
+}
+
+// ComputeDeckStats
+// Calculates the statistics of a deck from the scryfall card objects of its cards
+// Params: the resolved deck
+// Returns: the DeckStats
+func ComputeDeckStats(deck Deck) DeckStats {
+	stats := DeckStats{Types: make(map[string]int), Pips: make(map[string]int), Sources: make(map[string]int)}
+	manaValues := 0.0
+	for _, entry := range append(append([]DeckEntry{}, deck.Commanders...), deck.Cards...) {
+		if !entry.Card.Exists() {
+			stats.Unresolved += entry.Count
+			continue
+		}
+		cardType := PrimaryType(entry.Card)
+		stats.Types[cardType] += entry.Count
+		if cardType == "Land" {
+			stats.Lands += entry.Count
+			for _, color := range entry.Card.Get("produced_mana").Array() {
+				stats.Sources[color.String()] += entry.Count
+			}
+			continue
+		}
+		stats.Nonlands += entry.Count
+		manaValue := entry.Card.Get("cmc").Float()
+		manaValues += manaValue * float64(entry.Count)
+		stats.Curve[min(int(manaValue), MaxCurveManaValue)] += entry.Count
+		for color, pips := range CountManaPips(GetManaCost(entry.Card)) {
+			stats.Pips[color] += pips * entry.Count
+		}
+	}
+	if stats.Nonlands > 0 {
+		stats.AverageManaValue = manaValues / float64(stats.Nonlands)
+	}
+	return stats
+}
+
+// PrimaryType
+// Returns the type a card is grouped by, the first of CardTypes that is part of the type line of its front face
+func PrimaryType(card gjson.Result) string {
+	typeLine := card.Get("type_line").String()
+	if face := card.Get("card_faces.0.type_line"); face.Exists() {
+		typeLine = face.String()
+	}
+	typeLine, _, _ = strings.Cut(typeLine, "—") // only the card types count, not the subtypes
+	for _, cardType := range CardTypes {
+		if strings.Contains(typeLine, cardType) {
+			return cardType
+		}
+	}
+	return "Other"
+}
+
+// GetManaCost
+// Returns the mana cost of a card, the costs of all faces for multi-faced cards
+func GetManaCost(card gjson.Result) string {
+	if cost := card.Get("mana_cost").String(); cost != "" {
+		return cost
+	}
+	cost := ""
+	for _, face := range card.Get("card_faces").Array() {
+		cost += face.Get("mana_cost").String()
+	}
+	return cost
+}
+
+// CountManaPips
+// Counts the colored and colorless mana symbols of a mana cost, hybrid and phyrexian symbols count for each of their colors
+// Params: the mana cost, e.g. "{2}{W}{U/B}"
+// Returns: the number of symbols per color of ManaColors
+func CountManaPips(manaCost string) map[string]int {
+	pips := make(map[string]int)
+	for _, match := range manaSymbolRegex.FindAllStringSubmatch(manaCost, -1) {
+		for _, part := range strings.Split(match[1], "/") {
+			for _, color := range ManaColors {
+				if part == color {
+					pips[color]++
+				}
+			}
+		}
+	}
+	return pips
+}
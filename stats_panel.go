@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"image/color"
+	"strconv"
+)
+
+// manaColors are the colors the bars of each mana color are drawn with
+var manaColors = map[string]color.Color{
+	"W": color.NRGBA{R: 248, G: 231, B: 185, A: 255},
+	"U": color.NRGBA{R: 14, G: 104, B: 171, A: 255},
+	"B": color.NRGBA{R: 90, G: 80, B: 80, A: 255},
+	"R": color.NRGBA{R: 211, G: 32, B: 42, A: 255},
+	"G": color.NRGBA{R: 0, G: 115, B: 62, A: 255},
+	"C": color.NRGBA{R: 160, G: 160, B: 160, A: 255},
+}
+
+const (
+	statsBarWidth  = 22  // width of the columns of the mana curve
+	statsBarHeight = 100 // height of the highest column of the mana curve
+	statsBarLength = 160 // length of the longest horizontal bar
+)
+
+// NewStatsPanel
+// Creates the statistics panel, the statistics are only calculated once the user asks for them
+// Params: the session state
+// Returns: the panel
+func NewStatsPanel(state *SessionState) *LazyPanel {
+	return NewLazyPanel(state, "Analyze deck", "Analyzing deck...", func(source deckSource) (fyne.CanvasObject, error) {
+		resolved, err := source.resolvedDeck(state)
+		if err != nil {
+			return nil, err
+		}
+		return container.NewVScroll(NewStatsView(ComputeDeckStats(resolved.Deck))), nil
+	})
+}
+
+// NewStatsView
+// Draws the statistics of a deck: mana curve, types, lands and nonlands and the color pips next to the land color sources
+// Params: the DeckStats
+// Returns: the view of the statistics
+func NewStatsView(stats DeckStats) fyne.CanvasObject {
+	view := container.NewVBox()
+
+	// mana curve
+	view.Add(widget.NewLabelWithStyle(fmt.Sprintf("Mana curve (average %.2f)", stats.AverageManaValue), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	highest := 1
+	for _, count := range stats.Curve {
+		highest = max(highest, count)
+	}
+	curve := container.NewHBox()
+	for manaValue, count := range stats.Curve {
+		label := strconv.Itoa(manaValue)
+		if manaValue == MaxCurveManaValue {
+			label += "+"
+		}
+		height := float32(statsBarHeight * count / highest)
+		curve.Add(container.NewVBox(
+			newBar(color.Transparent, statsBarWidth, statsBarHeight-height),
+			newBar(theme.PrimaryColor(), statsBarWidth, height),
+			canvas.NewText(strconv.Itoa(count), theme.ForegroundColor()),
+			canvas.NewText(label, theme.DisabledColor()),
+		))
+	}
+	view.Add(curve)
+
+	// types
+	view.Add(widget.NewLabelWithStyle(fmt.Sprintf("Types (%d lands, %d nonlands)", stats.Lands, stats.Nonlands), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	highest = 1
+	for _, count := range stats.Types {
+		highest = max(highest, count)
+	}
+	types := container.New(layout.NewFormLayout())
+	for _, cardType := range append(append([]string{}, CardTypes...), "Other") {
+		if count := stats.Types[cardType]; count > 0 {
+			types.Add(widget.NewLabel(cardType))
+			types.Add(newHorizontalBar(theme.PrimaryColor(), count, highest))
+		}
+	}
+	view.Add(types)
+
+	// color pips and land sources
+	view.Add(widget.NewLabelWithStyle("Color pips / land sources", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	highest = 1
+	for _, manaColor := range ManaColors {
+		highest = max(highest, stats.Pips[manaColor], stats.Sources[manaColor])
+	}
+	pips := container.New(layout.NewFormLayout())
+	for _, manaColor := range ManaColors {
+		if stats.Pips[manaColor] == 0 && stats.Sources[manaColor] == 0 {
+			continue
+		}
+		pips.Add(widget.NewLabel(manaColor))
+		pips.Add(container.NewVBox(
+			newHorizontalBar(manaColors[manaColor], stats.Pips[manaColor], highest),
+			newHorizontalBar(theme.DisabledColor(), stats.Sources[manaColor], highest),
+		))
+	}
+	view.Add(pips)
+
+	if stats.Unresolved > 0 {
+		view.Add(widget.NewLabel(fmt.Sprintf("%d cards are unknown to Scryfall and not counted", stats.Unresolved)))
+	}
+	return view
+}
+
+// newBar creates a rectangle of a fixed size
+func newBar(fill color.Color, width float32, height float32) fyne.CanvasObject {
+	bar := canvas.NewRectangle(fill)
+	bar.SetMinSize(fyne.NewSize(width, height))
+	return bar
+}
+
+// newHorizontalBar creates a bar whose length is proportional to the value, followed by the value
+func newHorizontalBar(fill color.Color, value int, highest int) fyne.CanvasObject {
+	length := float32(statsBarLength * value / highest)
+	return container.NewHBox(newBar(fill, length, theme.TextSize()), canvas.NewText(strconv.Itoa(value), theme.ForegroundColor()))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// DefaultCardBackUrl is the regular magic card back on scryfall, used for cards without a card_back_id
+const DefaultCardBackUrl = "https://backs.scryfall.io/large/0/a/0aeebaf5-8c7d-4636-9e82-8c27447861f7.jpg"
+
+// ttsSave is a Tabletop Simulator saved object file
+type ttsSave struct {
+	SaveName     string      `json:"SaveName"`
+	GameMode     string      `json:"GameMode"`
+	Date         string      `json:"Date"`
+	Table        string      `json:"Table"`
+	Sky          string      `json:"Sky"`
+	Note         string      `json:"Note"`
+	Rules        string      `json:"Rules"`
+	XmlUI        string      `json:"XmlUI"`
+	LuaScript    string      `json:"LuaScript"`
+	ObjectStates []ttsObject `json:"ObjectStates"`
+}
+
+// ttsObject is a card or a deck of cards on the table
+type ttsObject struct {
+	Name             string                   `json:"Name"` // "Card" or "DeckCustom"
+	Transform        ttsTransform             `json:"Transform"`
+	Nickname         string                   `json:"Nickname"`
+	CardID           int                      `json:"CardID,omitempty"`
+	DeckIDs          []int                    `json:"DeckIDs,omitempty"`
+	CustomDeck       map[string]ttsCustomDeck `json:"CustomDeck"`
+	ContainedObjects []ttsObject              `json:"ContainedObjects,omitempty"`
+	States           map[string]ttsObject     `json:"States,omitempty"` // the back face of double faced cards
+}
+
+type ttsTransform struct {
+	PosX   float64 `json:"posX"`
+	PosY   float64 `json:"posY"`
+	PosZ   float64 `json:"posZ"`
+	RotX   float64 `json:"rotX"`
+	RotY   float64 `json:"rotY"`
+	RotZ   float64 `json:"rotZ"`
+	ScaleX float64 `json:"scaleX"`
+	ScaleY float64 `json:"scaleY"`
+	ScaleZ float64 `json:"scaleZ"`
+}
+
+// ttsCustomDeck is a card sheet, every image has its own sheet containing only that image
+type ttsCustomDeck struct {
+	FaceURL      string `json:"FaceURL"`
+	BackURL      string `json:"BackURL"`
+	NumWidth     int    `json:"NumWidth"`
+	NumHeight    int    `json:"NumHeight"`
+	BackIsHidden bool   `json:"BackIsHidden"`
+	UniqueBack   bool   `json:"UniqueBack"`
+	Type         int    `json:"Type"`
+}
+
+// exportTts
+// Formats a deck as a Tabletop Simulator saved object, the commanders are placed face up next to the face down deck
+// Params: the resolved deck, cards that could not be resolved are left out
+// Returns: the saved object as json, the names of the cards left out because scryfall has no image of them and an error if it could not be encoded
+func exportTts(deck Deck) (string, []string, error) {
+	noImage := make([]string, 0)
+	sheets := make(map[string]int) // the sheet of each image, copies of a card share it so the image is loaded once
+	newCard := func(name string, faceUrl string, backUrl string, transform ttsTransform) ttsObject {
+		sheet, ok := sheets[faceUrl+"|"+backUrl]
+		if !ok {
+			sheet = len(sheets) + 1
+			sheets[faceUrl+"|"+backUrl] = sheet
+		}
+		return ttsObject{
+			Name:       "Card",
+			Transform:  transform,
+			Nickname:   name,
+			CardID:     sheet * 100,
+			CustomDeck: map[string]ttsCustomDeck{strconv.Itoa(sheet): {FaceURL: faceUrl, BackURL: backUrl, NumWidth: 1, NumHeight: 1, BackIsHidden: true}},
+		}
+	}
+	// cardObject creates the card with the back face as a second state if the card has two faces with own images
+	cardObject := func(entry DeckEntry, transform ttsTransform) (ttsObject, bool) {
+		backUrl := DefaultCardBackUrl
+		if id := entry.Card.Get("card_back_id").String(); len(id) > 2 {
+			backUrl = "https://backs.scryfall.io/large/" + id[0:1] + "/" + id[1:2] + "/" + id + ".jpg"
+		}
+		if url := entry.Card.Get("image_uris.large").String(); url != "" {
+			return newCard(exportName(entry), url, backUrl, transform), true
+		}
+		faces := entry.Card.Get("card_faces").Array()
+		if len(faces) < 2 || faces[0].Get("image_uris.large").String() == "" {
+			return ttsObject{}, false
+		}
+		card := newCard(faces[0].Get("name").String(), faces[0].Get("image_uris.large").String(), backUrl, transform)
+		card.States = map[string]ttsObject{"2": newCard(faces[1].Get("name").String(), faces[1].Get("image_uris.large").String(), backUrl, transform)}
+		return card, true
+	}
+
+	faceDown := ttsTransform{PosY: 1, RotY: 180, RotZ: 180, ScaleX: 1, ScaleY: 1, ScaleZ: 1}
+	library := ttsObject{Name: "DeckCustom", Transform: faceDown, Nickname: deck.Name, DeckIDs: make([]int, 0), CustomDeck: make(map[string]ttsCustomDeck)}
+	for _, entry := range deck.Cards {
+		for i := 0; i < entry.Count; i++ {
+			card, ok := cardObject(entry, faceDown)
+			if !ok {
+				noImage = append(noImage, exportName(entry))
+				break
+			}
+			library.DeckIDs = append(library.DeckIDs, card.CardID)
+			for key, customDeck := range card.CustomDeck {
+				library.CustomDeck[key] = customDeck
+			}
+			library.ContainedObjects = append(library.ContainedObjects, card)
+		}
+	}
+
+	objects := []ttsObject{library}
+	for i, entry := range deck.Commanders {
+		faceUp := ttsTransform{PosX: float64(i+1) * 2.5, PosY: 1, RotY: 180, ScaleX: 1, ScaleY: 1, ScaleZ: 1}
+		if card, ok := cardObject(entry, faceUp); ok {
+			objects = append(objects, card)
+		} else {
+			noImage = append(noImage, exportName(entry))
+		}
+	}
+	content, err := json.MarshalIndent(ttsSave{ObjectStates: objects}, "", "  ")
+	return string(content), noImage, err
+}
+
+// TtsSavedObjectsDirectory
+// Returns the folder Tabletop Simulator loads saved objects from on the current operating system
+func TtsSavedObjectsDirectory() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(home, "Documents", "My Games", "Tabletop Simulator", "Saves", "Saved Objects")
+	case "darwin":
+		return filepath.Join(home, "Library", "Tabletop Simulator", "Saves", "Saved Objects")
+	default:
+		return filepath.Join(home, ".local", "share", "Tabletop Simulator", "Saves", "Saved Objects")
+	}
+}
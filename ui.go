@@ -0,0 +1,23 @@
+package main
+
+import "sync"
+
+// uiMutex serializes the event handlers of the windows with the results of background work applied to them.
+// Fyne 2.4 calls event handlers on its event goroutine but has no way to hand it a function,
+// so everything that reads or changes the history or the widgets outside of it takes this lock instead.
+var uiMutex sync.Mutex
+
+// RunOnUi applies the result of background work to the UI and the session state, never while an event handler runs
+func RunOnUi(apply func()) {
+	uiMutex.Lock()
+	defer uiMutex.Unlock()
+	apply()
+}
+
+// OnUi wraps an event handler, so results of background work are never applied while it runs.
+// The handler must not wait for background work that applies its result with RunOnUi.
+func OnUi(handler func()) func() {
+	return func() {
+		RunOnUi(handler)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// SavedVariantPrefix marks saved variants inside the variant selection of the main window
+const SavedVariantPrefix = "Saved: "
+
+const savedVariantsFile = "variants.json"
+
+// SavedVariant is a deck the user edited and saved under a name for a commander
+type SavedVariant struct {
+	Name       string   `json:"name"`
+	Commander  string   `json:"commander"` // the formatted name of the commander the variant is attached to
+	Commanders []string `json:"commanders"`
+	DeckList   string   `json:"deckList"`
+}
+
+// LoadSavedVariants
+// Reads all saved variants from the storage folder of the app
+// Params: the storage folder
+// Returns: the saved variants, none if nothing was saved yet, and an error if the file could not be read
+func LoadSavedVariants(directory string) ([]SavedVariant, error) {
+	content, err := os.ReadFile(filepath.Join(directory, savedVariantsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return make([]SavedVariant, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	variants := make([]SavedVariant, 0)
+	return variants, json.Unmarshal(content, &variants)
+}
+
+// SaveVariant
+// Stores a variant inside the storage folder of the app, a variant with the same name for the same commander is replaced
+// Params: the storage folder and the variant
+// Returns: an error if the variants could not be written
+func SaveVariant(directory string, variant SavedVariant) error {
+	if variant.Name == "" {
+		return errors.New("the variant needs a name")
+	}
+	variants, err := LoadSavedVariants(directory)
+	if err != nil {
+		return err
+	}
+	variants = slices.DeleteFunc(variants, func(v SavedVariant) bool {
+		return v.Commander == variant.Commander && v.Name == variant.Name
+	})
+	variants = append(variants, variant)
+	content, err := json.MarshalIndent(variants, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(directory, savedVariantsFile), content, 0644)
+}
+
+// GetSavedVariants
+// Returns the saved variants of a commander, variants without commanders (e.g. an edited variants file) are skipped
+// Params: the storage folder and the formatted name of the commander
+func GetSavedVariants(directory string, commander string) []SavedVariant {
+	variants, err := LoadSavedVariants(directory)
+	if err != nil {
+		fmt.Println("ERROR: " + err.Error())
+		return nil
+	}
+	return slices.DeleteFunc(variants, func(v SavedVariant) bool {
+		return v.Commander != commander || len(v.Commanders) == 0
+	})
+}
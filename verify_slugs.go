@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"io"
+	"slices"
+	"time"
+)
+
+// SlugVerificationDelay is the pause between two requests to EDHRec while slugs are verified
+var SlugVerificationDelay = 100 * time.Millisecond
+
+// VerifySlugs
+// Runs EdhrecSlug over every commander in a scryfall bulk file and checks that EDHRec knows a commander page for the slug.
+// Names that produce an empty slug, share their slug with another commander or whose page can not be found are reported.
+// Params: the path of the bulk file and the writer the report is written to
+// Returns: the number of names whose slug could not be resolved and an error if the bulk file could not be read
+func VerifySlugs(bulkPath string, out io.Writer) (int, error) {
+	names := make(map[string][]string) // commander names keyed by their slug
+	count := 0                         // number of distinct commander names
+	err := ForEachScryfallBulkCard(bulkPath, func(card gjson.Result) error {
+		if IsCommanderCard(card) {
+			name := card.Get("name").String()
+			slug := EdhrecSlug(name)
+			if !slices.Contains(names[slug], name) { // bulk files may contain every printing of a card
+				names[slug] = append(names[slug], name)
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	fmt.Fprintf(out, "verifying %d slugs of %d names\n", len(names), count)
+
+	unresolved := 0
+	slugs := make([]string, 0, len(names))
+	for slug := range names {
+		slugs = append(slugs, slug)
+	}
+	slices.Sort(slugs)
+	for _, slug := range slugs {
+		cardNames := names[slug]
+		switch {
+		case slug == "":
+			fmt.Fprintf(out, "EMPTY     %q\n", cardNames)
+			unresolved += len(cardNames)
+			continue
+		case len(cardNames) > 1:
+			fmt.Fprintf(out, "COLLISION %s %q\n", slug, cardNames)
+		}
+		_, err := getEdhrecJson(EdhrecJsonBaseUrl + "/commanders/" + slug + ".json")
+		if errors.Is(err, errEdhrecNotFound) {
+			fmt.Fprintf(out, "NOT FOUND %s %q\n", slug, cardNames)
+			unresolved += len(cardNames)
+		} else if err != nil {
+			fmt.Fprintf(out, "ERROR     %s %q: %s\n", slug, cardNames, err.Error())
+			unresolved += len(cardNames)
+		} else { // the page belongs to at most one of the colliding names
+			unresolved += len(cardNames) - 1
+		}
+		time.Sleep(SlugVerificationDelay)
+	}
+	fmt.Fprintf(out, "%d of %d names could not be resolved\n", unresolved, count)
+	return unresolved, nil
+}